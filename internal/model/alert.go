@@ -0,0 +1,58 @@
+package model
+
+import (
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// alertInterval is the minimum time between alerts. Matching records that
+// arrive sooner after an alert are not alerted on, so that a burst of errors
+// produces a single alert.
+const alertInterval = 5 * time.Second
+
+// alertFlashDuration is how long the border of the output window is flashed
+// for an alert.
+const alertFlashDuration = 300 * time.Millisecond
+
+// endAlertFlash is a tea.Msg that ends the flash of the alert with the given
+// id.
+type endAlertFlash struct {
+	id int
+}
+
+// alert rings the terminal bell and flashes the border of the output window,
+// unless an alert was raised within the last alertInterval. It returns a
+// tea.Cmd that ends the flash.
+func (m *Model) alert() tea.Cmd {
+	now := time.Now()
+	if now.Sub(m.lastAlert) < alertInterval {
+		return nil
+	}
+	m.lastAlert = now
+	m.alertID++
+	id := m.alertID
+	return tea.Batch(
+		ringBell,
+		tea.Tick(alertFlashDuration, func(time.Time) tea.Msg {
+			return endAlertFlash{id: id}
+		}),
+	)
+}
+
+// ringBell is a tea.Cmd that rings the terminal bell. It returns no message.
+func ringBell() tea.Msg {
+	os.Stdout.WriteString("\a")
+	return nil
+}
+
+// outputStyle returns the given style with a red border while an alert is
+// being flashed.
+func (m *Model) outputStyle(style lipgloss.Style) lipgloss.Style {
+	if m.alertID != m.endedAlertID {
+		return style.BorderForeground(m.theme.alert)
+	}
+	return style
+}
@@ -0,0 +1,197 @@
+package model
+
+import (
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// browseHelp is shown in the footer while the path browser is open.
+const browseHelp = "enter: use path  right: open  left: back  esc: cancel"
+
+// browseKey is a key of the objects at the current level of the path browser.
+// Branch indicates that the key holds objects with keys of their own.
+type browseKey struct {
+	key    string
+	branch bool
+}
+
+// openBrowse shows the path browser, where the selector can be built by
+// drilling into the keys of the objects in the file, in place of the output.
+// The browser starts at the parent of the current selector if it is a simple
+// path.
+func (m *Model) openBrowse() tea.Cmd {
+	m.browsing = true
+	m.browsePaths = nil
+	m.browseCrumbs = nil
+	m.browseIndex = 0
+	m.browseSelect = ""
+	if crumbs := m.selectorKeys(); len(crumbs) > 0 {
+		m.browseCrumbs = crumbs[:len(crumbs)-1]
+		m.browseSelect = crumbs[len(crumbs)-1]
+	}
+	m.updateOutputModelContent()
+	return m.listPaths
+}
+
+// closeBrowse hides the path browser and restores the content of the output
+// window.
+func (m *Model) closeBrowse() {
+	m.browsing = false
+	m.updateOutputModelContent()
+}
+
+// selectorKeys returns the keys of the current selector if it is a simple path,
+// like ".a.b", or a JSON pointer in pointer mode. Otherwise nil is returned.
+func (m *Model) selectorKeys() []string {
+	selector := m.selectorModel.Value()
+	if m.pointer {
+		keys, _ := processor.PointerTokens(selector)
+		return keys
+	}
+	if !strings.HasPrefix(selector, ".") || strings.ContainsAny(selector, " |[]()\"") {
+		return nil
+	}
+	keys := strings.Split(selector[1:], ".")
+	if slices.Contains(keys, "") {
+		return nil
+	}
+	return keys
+}
+
+// handleBrowseMessage handles messages sent to the path browser. Up and down
+// choose a key, right opens it, left goes back to the parent, enter uses the
+// path to the chosen key as the selector, and esc closes the browser.
+func (m *Model) handleBrowseMessage(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	keys := m.browseKeys()
+	switch keyMsg.String() {
+	case "esc":
+		m.closeBrowse()
+		return m, nil
+	case "up", "k":
+		m.browseIndex = max(m.browseIndex-1, 0)
+	case "down", "j":
+		m.browseIndex = min(m.browseIndex+1, max(len(keys)-1, 0))
+	case "right", "l":
+		if m.browseIndex < len(keys) && keys[m.browseIndex].branch {
+			m.browseCrumbs = append(m.browseCrumbs, keys[m.browseIndex].key)
+			m.browseIndex = 0
+		}
+	case "left", "h", "backspace":
+		if len(m.browseCrumbs) > 0 {
+			parent := m.browseCrumbs[len(m.browseCrumbs)-1]
+			m.browseCrumbs = m.browseCrumbs[:len(m.browseCrumbs)-1]
+			m.browseIndex = max(slices.IndexFunc(m.browseKeys(), func(k browseKey) bool { return k.key == parent }), 0)
+		}
+	case "enter":
+		if m.browseIndex >= len(keys) {
+			return m, nil
+		}
+		m.closeBrowse()
+		m.selectorModel.SetValue(m.selectorSyntax(m.browsePath(keys[m.browseIndex].key)))
+		m.selectorInvalid = false
+		return m, m.reloadGroups
+	}
+	m.updateOutputModelContent()
+	return m, nil
+}
+
+// handleProcessorPathList handles the processor.PathList message. This message
+// conveys the paths that the path browser navigates.
+func (m *Model) handleProcessorPathList(msg processor.PathList) (tea.Model, tea.Cmd) {
+	if !m.browsing {
+		return m, nil
+	}
+	if msg.Err != nil {
+		m.closeBrowse()
+		return m, m.setStatus("paths: " + msg.Err.Error())
+	}
+	m.browsePaths = msg.Paths
+	m.browseIndex = max(slices.IndexFunc(m.browseKeys(), func(k browseKey) bool { return k.key == m.browseSelect }), 0)
+	m.updateOutputModelContent()
+	return m, nil
+}
+
+// browsePath returns the path to the given key at the current level of the
+// path browser.
+func (m *Model) browsePath(key string) string {
+	return "." + strings.Join(append(slices.Clone(m.browseCrumbs), key), ".")
+}
+
+// browseKeys returns the keys at the current level of the path browser in the
+// order of the sampled paths.
+func (m *Model) browseKeys() []browseKey {
+	prefix := "."
+	if len(m.browseCrumbs) > 0 {
+		prefix = m.browsePath("") // ends with "."
+	}
+	var keys []browseKey
+	indexes := map[string]int{}
+	for _, path := range m.browsePaths {
+		rest, ok := strings.CutPrefix(path, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+		key, _, branch := strings.Cut(rest, ".")
+		if idx, ok := indexes[key]; ok {
+			keys[idx].branch = keys[idx].branch || branch
+			continue
+		}
+		indexes[key] = len(keys)
+		keys = append(keys, browseKey{key: key, branch: branch})
+	}
+	return keys
+}
+
+// browseContent returns the path browser formatted for the output window: the
+// breadcrumb of the current level followed by its keys. Keys that can be
+// opened are marked and the chosen key is highlighted.
+func (m *Model) browseContent() string {
+	if m.browsePaths == nil {
+		return "Loading..."
+	}
+	lines := []string{strings.Join(append([]string{"."}, m.browseCrumbs...), " › ")}
+	selectedStyle := lipgloss.NewStyle().Reverse(true)
+	for i, key := range m.browseKeys() {
+		line := "  " + key.key
+		if key.branch {
+			line = "▸ " + key.key
+		}
+		if i == m.browseIndex {
+			line = selectedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 1 {
+		lines = append(lines, "(no fields)")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// scrollBrowse scrolls the output window so that the chosen key of the path
+// browser is visible.
+func (m *Model) scrollBrowse() {
+	row := m.browseIndex + 1
+	if row < m.outputModel.YOffset {
+		m.outputModel.SetYOffset(row)
+	} else if row >= m.outputModel.YOffset+m.outputModel.Height {
+		m.outputModel.SetYOffset(row - m.outputModel.Height + 1)
+	}
+}
+
+// listPaths is a tea.Cmd that issues a processor.ListPathsOperation to the
+// currently connected processor. It returns no message.
+func (m *Model) listPaths() tea.Msg {
+	m.processorCmdChan <- processor.Command{
+		Operation: processor.ListPathsOperation,
+		Path:      m.path,
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// groupsRequested is a tea.Msg that indicates groups have been requested from
+// the processor.
+type groupsRequested struct{}
+
+// busy returns true while the processor is reading content or groups that the
+// model is waiting for.
+func (m *Model) busy() bool {
+	return m.loading || m.readingGroups || m.refreshingGroups || m.loadingEarlier
+}
+
+// startSpinner returns the tea.Cmd that starts the footer spinner, or nil if it
+// is already spinning. The spinner stops by itself once the model is no longer
+// busy.
+func (m *Model) startSpinner() tea.Cmd {
+	if m.spinning {
+		return nil
+	}
+	m.spinning = true
+	return m.spinner.Tick
+}
+
+// handleSpinnerTick handles the spinner.TickMsg message. The spinner advances
+// while the model is busy and stops otherwise.
+func (m *Model) handleSpinnerTick(msg spinner.TickMsg) (tea.Model, tea.Cmd) {
+	if !m.busy() {
+		m.spinning = false
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	return m, cmd
+}
+
+// busyIndicator returns the spinner shown in the footer while the model is
+// busy, or "" otherwise.
+func (m *Model) busyIndicator() string {
+	if !m.spinning || !m.busy() {
+		return ""
+	}
+	return m.spinner.View()
+}
@@ -0,0 +1,165 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// defaultContextSize is the number of lines of context shown before and after
+// each matching record when the context view is opened.
+const defaultContextSize = 3
+
+// maxContextSize is the largest number of lines of context that can be shown
+// before and after each matching record.
+const maxContextSize = 50
+
+// contextState holds the state of the context view, which shows the raw lines
+// of the file around the records of the selected group, like grep -C.
+type contextState struct {
+	size   int
+	ranges []processor.ContextRange
+	// matches holds the line numbers of the records of the selected group.
+	matches map[int]bool
+	loaded  bool
+	err     error
+	// running is set while the processor reads the context, and pending is
+	// set if the context changed during that read and must be read again.
+	running bool
+	pending bool
+}
+
+// openContext shows the context view in place of the output. The records must
+// be loaded to know their line numbers, so the content is reloaded first if
+// they are not.
+func (m *Model) openContext() tea.Cmd {
+	if m.showHistogram {
+		m.closeHistogram()
+	}
+	if m.showTimeline {
+		m.closeTimeline()
+	}
+	m.showContext = true
+	m.context.ranges = nil
+	m.context.loaded = false
+	m.updateOutputModelContent()
+	if cmd := m.reloadContentForRecords(); cmd != nil {
+		// The context is read once the records arrive.
+		return cmd
+	}
+	return m.refreshContext()
+}
+
+// closeContext hides the context view and restores the content of the output
+// window.
+func (m *Model) closeContext() {
+	m.showContext = false
+	m.updateOutputModelContent()
+}
+
+// resizeContext changes the number of lines of context by the given amount and
+// reads the context again.
+func (m *Model) resizeContext(delta int) tea.Cmd {
+	m.context.size = min(max(m.context.size+delta, 0), maxContextSize)
+	return m.refreshContext()
+}
+
+// refreshContext returns the tea.Cmd that reads the context of the records
+// shown in the output, or nil if the context view is closed. If a read is
+// already running then the context is read again once it is done.
+func (m *Model) refreshContext() tea.Cmd {
+	if !m.showContext {
+		return nil
+	}
+	if m.context.running {
+		m.context.pending = true
+		return nil
+	}
+	m.context.running = true
+	lines := m.matchLines()
+	m.context.matches = make(map[int]bool, len(lines))
+	for _, line := range lines {
+		m.context.matches[line] = true
+	}
+	cmd := processor.Command{
+		Operation: processor.RunContextOperation,
+		Path:      m.path,
+		Lines:     lines,
+		Context:   m.context.size,
+	}
+	return func() tea.Msg {
+		m.processorCmdChan <- cmd
+		return nil
+	}
+}
+
+// matchLines returns the line numbers of the records shown in the output.
+func (m *Model) matchLines() []int {
+	var lines []int
+	m.eachOutputLine(func(idx, count int) bool {
+		if idx < len(m.rawOutputRecords) {
+			if line := m.rawOutputRecords[idx].Line; line > 0 && (len(lines) == 0 || lines[len(lines)-1] != line) {
+				lines = append(lines, line)
+			}
+		}
+		return true
+	})
+	return lines
+}
+
+// handleProcessorContextLines handles the processor.ContextLines message. This
+// message conveys the lines of the file around the records shown in the
+// output. They are shown in the output window while the context view is open.
+func (m *Model) handleProcessorContextLines(msg processor.ContextLines) (tea.Model, tea.Cmd) {
+	m.context.running = false
+	if !m.showContext {
+		return m, nil
+	}
+	m.context.ranges = msg.Ranges
+	m.context.err = msg.Err
+	m.context.loaded = true
+	m.updateOutputModelContent()
+	if m.context.pending {
+		m.context.pending = false
+		return m, m.refreshContext()
+	}
+	return m, nil
+}
+
+// contextContent returns the context view formatted for the output window.
+// Each line is prefixed with its line number, followed by ":" for the lines of
+// matching records and "-" for the lines around them. Ranges that are not
+// adjacent are separated by "--".
+func (m *Model) contextContent() string {
+	switch {
+	case m.context.err != nil:
+		return m.context.err.Error()
+	case !m.context.loaded:
+		return "Loading..."
+	case len(m.context.ranges) == 0:
+		return "(no matching records)"
+	}
+	var lines []string
+	for i, r := range m.context.ranges {
+		if i > 0 {
+			lines = append(lines, "--")
+		}
+		for j, line := range r.Lines {
+			number := r.Start + j
+			separator := "-"
+			if m.context.matches[number] {
+				separator = ":"
+			}
+			lines = append(lines, formatContentLine(m.formatOptions(), fmt.Sprintf("%6d%s ", number, separator), line)...)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// contextHelp returns the text shown in the footer while the context view is
+// open.
+func (m *Model) contextHelp() string {
+	return fmt.Sprintf("%d lines of context  +/-: more/less  esc: close", m.context.size)
+}
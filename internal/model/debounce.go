@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// debouncedReload is a tea.Msg that reloads the groups or content for an edit
+// of the selector or format window if no later edit of that window has been
+// made since.
+type debouncedReload struct {
+	window selectedWindowIndex
+	id     int
+}
+
+// debounceReload returns the tea.Cmd that reloads the groups, for an edit of
+// the selector, or the content, for an edit of the format, once the reload
+// debounce interval has passed without another edit of the same window. With
+// no interval the reload is returned directly.
+func (m *Model) debounceReload(window selectedWindowIndex) tea.Cmd {
+	if m.reloadDebounce <= 0 {
+		return m.debouncedReloadCmd(window)
+	}
+	m.reloadIDs[window]++
+	msg := debouncedReload{window: window, id: m.reloadIDs[window]}
+	return tea.Tick(m.reloadDebounce, func(time.Time) tea.Msg {
+		return msg
+	})
+}
+
+// handleDebouncedReload handles the debouncedReload message. Reloads that were
+// superseded by a later edit are dropped, as are reloads for a selector that
+// became implausible.
+func (m *Model) handleDebouncedReload(msg debouncedReload) (tea.Model, tea.Cmd) {
+	if msg.id != m.reloadIDs[msg.window] {
+		return m, nil
+	}
+	return m, m.debouncedReloadCmd(msg.window)
+}
+
+// debouncedReloadCmd returns the tea.Cmd that reloads what depends on the given
+// window, or nil if the selector is not plausible.
+func (m *Model) debouncedReloadCmd(window selectedWindowIndex) tea.Cmd {
+	if window == formatWindow {
+		return m.reloadContent
+	}
+	if m.selectorInvalid {
+		return nil
+	}
+	return m.reloadGroups
+}
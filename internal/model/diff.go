@@ -0,0 +1,61 @@
+package model
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// diffLine returns the line to display for the record that produced the
+// content line at the given index when the diff view is enabled. Only the
+// first line of each record is displayed. It shows the top level fields that
+// were added or changed since the previous record as key=value and the fields
+// that were removed as -key. It returns false if the record is not an object,
+// in which case the line is displayed normally.
+func (m *Model) diffLine(idx int) (string, bool, bool) {
+	record := m.rawOutputRecords[idx]
+	current, ok := parseObject(record.JSON)
+	if !ok {
+		return "", false, false
+	}
+	if idx > 0 && m.rawOutputRecords[idx-1] == record {
+		return "", true, true
+	}
+	previous := map[string]any{}
+	if idx > 0 {
+		// Skip back over the lines of the previous record to find where it
+		// started.
+		prevIdx := idx - 1
+		for prevIdx > 0 && m.rawOutputRecords[prevIdx-1] == m.rawOutputRecords[prevIdx] {
+			prevIdx--
+		}
+		if object, ok := parseObject(m.rawOutputRecords[prevIdx].JSON); ok {
+			previous = object
+		}
+	}
+	return recordDiff(previous, current), false, true
+}
+
+// recordDiff returns a one line summary of the differences between the top
+// level fields of the given objects.
+func recordDiff(previous, current map[string]any) string {
+	var parts []string
+	for _, key := range slices.Sorted(maps.Keys(current)) {
+		if value, ok := previous[key]; ok && reflect.DeepEqual(value, current[key]) {
+			continue
+		}
+		value, _ := json.Marshal(current[key])
+		parts = append(parts, key+"="+string(value))
+	}
+	for _, key := range slices.Sorted(maps.Keys(previous)) {
+		if _, ok := current[key]; !ok {
+			parts = append(parts, "-"+key)
+		}
+	}
+	if len(parts) == 0 {
+		return "(no changes)"
+	}
+	return strings.Join(parts, " ")
+}
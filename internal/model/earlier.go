@@ -0,0 +1,85 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// earlierLinesPending returns true if lines of the file before the loaded
+// content are still to be read because only the end of the file was loaded.
+func (m *Model) earlierLinesPending() bool {
+	return m.firstLine > max(m.fromLine, 1)
+}
+
+// loadEarlierAtTop returns a tea.Cmd that reads the lastLines lines of the file
+// before the loaded content if the output window is scrolled to the top of the
+// content and there are earlier lines. It returns nil otherwise.
+func (m *Model) loadEarlierAtTop() tea.Cmd {
+	if !m.earlierLinesPending() || m.loadingEarlier || m.loading || m.outputReplaced() || m.outputOffset > 0 {
+		return nil
+	}
+	m.loadingEarlier = true
+	cmd := m.contentCommand()
+	cmd.Operation = processor.LoadEarlierOperation
+	cmd.FromLine = max(m.firstLine-m.lastLines, m.fromLine, 1)
+	cmd.ToLine = m.firstLine - 1
+	return tea.Batch(m.startSpinner(), func() tea.Msg {
+		m.processorCmdChan <- cmd
+		return nil
+	})
+}
+
+// handleProcessorEarlierContent handles the processor.EarlierContent message.
+// This message conveys the content of the lines of the file before the loaded
+// content. It is put before the loaded content and the output window is
+// scrolled so that the rows that were shown stay in place. Content for lines
+// that no longer precede the loaded content, as after a reload, is ignored.
+func (m *Model) handleProcessorEarlierContent(msg processor.EarlierContent) (tea.Model, tea.Cmd) {
+	if !m.loadingEarlier || msg.LastLine != m.firstLine-1 {
+		return m, nil
+	}
+	m.loadingEarlier = false
+	if msg.Err != nil {
+		return m, m.setStatus("load earlier lines: " + msg.Err.Error())
+	}
+	m.firstLine = msg.FirstLine
+	count := len(msg.Content)
+	m.rawOutputContent = append(slices.Clone(msg.Content), m.rawOutputContent...)
+	m.rawOutputRecords = append(slices.Clone(msg.Records), m.rawOutputRecords...)
+	// The indexes of the loaded lines moved.
+	m.formatCache = nil
+	if m.selection.active {
+		m.selection.anchor += count
+		m.selection.end += count
+	}
+	m.markRow = -1
+	m.lastTimeRecord = processor.Record{}
+	for _, record := range msg.Records {
+		m.updateTimeRange(record)
+		m.severity.add(record)
+	}
+	rows := m.outputRowCount()
+	offset := m.outputOffset
+	m.updateOutputModelContent()
+	if !m.outputReplaced() {
+		m.setOutputYOffset(offset + m.outputRowCount() - rows)
+	}
+	// Lines that produce no content, as when a group is selected, leave the
+	// output at the top, so the lines before them are read too.
+	return m, m.loadEarlierAtTop()
+}
+
+// earlierLinesStatus returns the note shown in the footer while lines of the
+// file before the loaded content are unread, or "" if there are none.
+func (m *Model) earlierLinesStatus() string {
+	switch {
+	case m.loadingEarlier:
+		return fmt.Sprintf("loading lines before %d", m.firstLine)
+	case m.earlierLinesPending():
+		return fmt.Sprintf("from line %d", m.firstLine)
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// numberedLines returns the lines "line <first>" to "line <last>".
+func numberedLines(first, last int) []string {
+	var lines []string
+	for n := first; n <= last; n++ {
+		lines = append(lines, fmt.Sprintf("line %d", n))
+	}
+	return lines
+}
+
+func TestHandleProcessorEarlierContent(t *testing.T) {
+	m := newTestModel(60, 30, numberedLines(101, 200))
+	for i := range m.rawOutputRecords {
+		m.rawOutputRecords[i] = processor.Record{Line: 101 + i}
+	}
+	m.firstLine = 101
+	m.lastLines = 100
+	m.loadingEarlier = true
+	m.setOutputYOffset(10)
+	m.selection = selection{active: true, anchor: 12, end: 14}
+	top := strings.Split(m.outputModel.View(), "\n")[0]
+	if !strings.Contains(top, "line 111") {
+		t.Fatalf("top row before loading is %q", top)
+	}
+
+	// Content for lines that no longer precede the loaded content is
+	// ignored.
+	m.handleProcessorEarlierContent(processor.EarlierContent{Content: []string{"stale"}, FirstLine: 1, LastLine: 50})
+	if len(m.rawOutputContent) != 100 || !m.loadingEarlier || m.firstLine != 101 {
+		t.Fatalf("stale content was used: %d lines, first line %d", len(m.rawOutputContent), m.firstLine)
+	}
+
+	earlier := numberedLines(1, 100)
+	records := make([]processor.Record, len(earlier))
+	for i := range records {
+		records[i] = processor.Record{Line: 1 + i}
+	}
+	m.handleProcessorEarlierContent(processor.EarlierContent{Content: earlier, Records: records, FirstLine: 1, LastLine: 100})
+	if m.loadingEarlier || m.firstLine != 1 || m.earlierLinesPending() {
+		t.Errorf("got loadingEarlier %v and first line %d, want false and 1", m.loadingEarlier, m.firstLine)
+	}
+	if len(m.rawOutputContent) != 200 || m.rawOutputContent[0] != "line 1" || m.rawOutputContent[100] != "line 101" {
+		t.Fatalf("earlier lines were not prepended: %d lines starting %q", len(m.rawOutputContent), m.rawOutputContent[0])
+	}
+	if len(m.rawOutputRecords) != 200 || m.rawOutputRecords[0].Line != 1 || m.rawOutputRecords[100].Line != 101 {
+		t.Errorf("earlier records were not prepended")
+	}
+	if m.outputOffset != 110 {
+		t.Errorf("got offset %d, want 110", m.outputOffset)
+	}
+	if got := strings.Split(m.outputModel.View(), "\n")[0]; got != top {
+		t.Errorf("top row moved from %q to %q", top, got)
+	}
+	if m.selection != (selection{active: true, anchor: 112, end: 114}) {
+		t.Errorf("got selection %+v, want it shifted by 100 lines", m.selection)
+	}
+
+	// A late duplicate of the same content is ignored once it was used.
+	m.handleProcessorEarlierContent(processor.EarlierContent{Content: earlier, Records: records, FirstLine: 1, LastLine: 100})
+	if len(m.rawOutputContent) != 200 {
+		t.Errorf("duplicate content was prepended: %d lines", len(m.rawOutputContent))
+	}
+}
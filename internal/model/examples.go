@@ -0,0 +1,133 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// examplesHelp is shown in the footer while the example picker is open.
+const examplesHelp = "enter: use example  up/down: choose  esc: cancel"
+
+// jqExample is an example expression offered by the example picker.
+type jqExample struct {
+	expr string
+	desc string
+}
+
+// selectorExamples are offered for the selector when it is a path.
+var selectorExamples = []jqExample{
+	{`.level`, "group by a top level field"},
+	{`.properties.logger`, "group by a nested field"},
+	{`.tags`, "group by the elements of an array"},
+}
+
+// rawSelectorExamples are offered for the selector when it is a jq filter.
+var rawSelectorExamples = []jqExample{
+	{`select(.level == "error")`, "records with a field equal to a value"},
+	{`select(.level == "error" or .level == "warn")`, "records matching either value"},
+	{`select(.message | contains("timeout"))`, "records whose string contains text"},
+	{`select(.message | test("^conn"; "i"))`, "records whose string matches a regex, ignoring case"},
+	{`select(.status >= 500)`, "records with a number above a limit"},
+	{`select(has("error"))`, "records that have a field"},
+	{`select(.tags | index(["db"]))`, "records whose array holds a value"},
+}
+
+// formatExamples are offered for the format.
+var formatExamples = []jqExample{
+	{`.message`, "one field"},
+	{`ts,level,message`, "several fields joined with spaces"},
+	{`"\(.level): \(.message)"`, "fields interpolated into a string"},
+	{`{level, message}`, "an object of some fields"},
+	{`del(.properties)`, "the record without a field"},
+	{`.properties | keys`, "the keys of a nested object"},
+	{`.message | ascii_downcase`, "a string in lower case"},
+	{`(.elapsed | rtrimstr("ms") | tonumber)`, "a number from a string like \"25ms\""},
+}
+
+// openExamples shows the example picker for the selector or format window in
+// place of the output.
+func (m *Model) openExamples(target selectedWindowIndex) {
+	m.showExamples = true
+	m.examplesTarget = target
+	m.examplesIndex = 0
+	m.updateOutputModelContent()
+}
+
+// closeExamples hides the example picker and restores the content of the
+// output window.
+func (m *Model) closeExamples() {
+	m.showExamples = false
+	m.updateOutputModelContent()
+}
+
+// examples returns the examples offered for the window the picker was opened
+// from. Selector paths are given as JSON pointers in pointer mode.
+func (m *Model) examples() []jqExample {
+	switch {
+	case m.examplesTarget == formatWindow:
+		return formatExamples
+	case m.rawSelector:
+		return rawSelectorExamples
+	}
+	examples := make([]jqExample, len(selectorExamples))
+	for i, example := range selectorExamples {
+		examples[i] = jqExample{expr: m.selectorSyntax(example.expr), desc: example.desc}
+	}
+	return examples
+}
+
+// handleExamplesMessage handles messages sent to the example picker. Up and
+// down choose an example, enter puts it in the input the picker was opened
+// from, and esc closes the picker.
+func (m *Model) handleExamplesMessage(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	examples := m.examples()
+	switch keyMsg.String() {
+	case "esc":
+		m.closeExamples()
+		return m, nil
+	case "up", "k":
+		m.examplesIndex = max(m.examplesIndex-1, 0)
+	case "down", "j":
+		m.examplesIndex = min(m.examplesIndex+1, len(examples)-1)
+	case "enter":
+		m.closeExamples()
+		expr := examples[m.examplesIndex].expr
+		if m.examplesTarget == formatWindow {
+			m.formatModel.SetValue(expr)
+			return m, m.reloadContent
+		}
+		m.selectorModel.SetValue(expr)
+		m.selectorInvalid = false
+		return m, m.reloadGroups
+	}
+	m.updateOutputModelContent()
+	return m, nil
+}
+
+// examplesContent returns the example picker formatted for the output window:
+// each example followed by what it does, with the chosen example highlighted.
+func (m *Model) examplesContent() string {
+	examples := m.examples()
+	width := 0
+	for _, example := range examples {
+		width = max(width, lipgloss.Width(example.expr))
+	}
+	selectedStyle := lipgloss.NewStyle().Reverse(true)
+	faint := lipgloss.NewStyle().Faint(true)
+	lines := make([]string, len(examples))
+	for i, example := range examples {
+		expr := fmt.Sprintf("%-*s", width, example.expr)
+		if i == m.examplesIndex {
+			expr = selectedStyle.Render(expr)
+		}
+		lines[i] = expr + "  " + faint.Render(example.desc)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// excludedMarker precedes the groups in the groups window whose records are
+// left out of the output.
+const excludedMarker = "≠ "
+
+// toggleExcludedGroup leaves the records of the highlighted group out of the
+// output, whichever group is selected, or brings them back if they were left
+// out, and reloads the content. The "*" group cannot be excluded. Exclusions
+// are forgotten when the groups are read for a new selector.
+func (m *Model) toggleExcludedGroup() tea.Cmd {
+	selectedItem := m.groupsModel.SelectedItem()
+	if selectedItem == nil || selectedItem.FilterValue() == "*" {
+		return m.setStatus("choose a group to exclude")
+	}
+	group := selectedItem.FilterValue()
+	if i := slices.Index(m.excludedGroups, group); i >= 0 {
+		m.excludedGroups = slices.Delete(slices.Clone(m.excludedGroups), i, i+1)
+	} else {
+		m.excludedGroups = append(slices.Clone(m.excludedGroups), group)
+	}
+	m.updateGroupWidth()
+	return m.reloadContent
+}
+
+// excludedWidth returns the width that the excludedMarker adds to the groups
+// window, or 0 if no group is excluded.
+func (m *Model) excludedWidth() int {
+	if len(m.excludedGroups) == 0 {
+		return 0
+	}
+	return lipgloss.Width(excludedMarker)
+}
@@ -0,0 +1,164 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// explainHelp is shown in the footer while the explanation is open.
+const explainHelp = "?/esc: close explanation"
+
+// explainStage is a stage of the jq pipeline: what it does in words and the
+// jq that does it.
+type explainStage struct {
+	text string
+	jq   string
+}
+
+// openExplain shows the stages of the jq pipeline in place of the output.
+func (m *Model) openExplain() {
+	m.showExplain = true
+	m.outputModel.GotoTop()
+	m.updateOutputModelContent()
+}
+
+// closeExplain hides the explanation and restores the content of the output
+// window.
+func (m *Model) closeExplain() {
+	m.showExplain = false
+	m.updateOutputModelContent()
+}
+
+// explainStages returns the stages of the jq pipeline for the current
+// selector, group, and format, in the order jq runs them. They are built from
+// the settings of the model rather than by parsing the jq command.
+func (m *Model) explainStages() []explainStage {
+	stages := []explainStage{{"read each line of the file as JSON", ".|fromjson"}}
+	if m.relaxed {
+		stages[0].text += ", after turning relaxed JSON into standard JSON"
+	}
+	for _, path := range m.redact {
+		stages = append(stages, explainStage{
+			fmt.Sprintf("replace the value at %s with \"***\" where it exists", path),
+			fmt.Sprintf(`if %s? != null then %s="***" else . end`, path, path),
+		})
+	}
+	if stage, ok := m.timeWindowStage(); ok {
+		stages = append(stages, stage)
+	}
+	if m.threshold.active() {
+		value := strconv.FormatFloat(m.threshold.value(), 'g', -1, 64)
+		stages = append(stages, explainStage{
+			fmt.Sprintf("keep records whose %s is a number of at least %s", m.threshold.field, value),
+			fmt.Sprintf("select((%s|numbers) >= %s)", m.threshold.field, value),
+		})
+	}
+	stages = append(stages, m.filterStages()...)
+	stages = append(stages, m.formatStages()...)
+	if m.lenient {
+		stages = append(stages, explainStage{"skip records that cause a jq error instead of reporting it", "try (...) catch empty"})
+	}
+	return stages
+}
+
+// timeWindowStage returns the stage that keeps the records in the time window,
+// or false if there is no window.
+func (m *Model) timeWindowStage() (explainStage, bool) {
+	switch {
+	case m.timeField == "" || (m.since.IsZero() && m.until.IsZero()):
+		return explainStage{}, false
+	case m.until.IsZero():
+		return explainStage{fmt.Sprintf("keep records whose %s is at or after %s", m.timeField, m.since.Format(time.RFC3339)), "select(" + m.timeField + " >= ...)"}, true
+	case m.since.IsZero():
+		return explainStage{fmt.Sprintf("keep records whose %s is at or before %s", m.timeField, m.until.Format(time.RFC3339)), "select(" + m.timeField + " <= ...)"}, true
+	}
+	return explainStage{fmt.Sprintf("keep records whose %s is from %s to %s", m.timeField, m.since.Format(time.RFC3339), m.until.Format(time.RFC3339)), "select(" + m.timeField + " >= ... and " + m.timeField + " <= ...)"}, true
+}
+
+// filterStages returns the stages that select the records of the selected
+// group and leave out the excluded groups.
+func (m *Model) filterStages() []explainStage {
+	selector := m.selectorModel.Value()
+	group := m.selectedGroup()
+	if m.rawSelector {
+		if selector == "" {
+			return nil
+		}
+		return []explainStage{{"run the filter on each record and keep what it outputs; with select, that is the records it matches", selector}}
+	}
+	if m.pointer {
+		if path, err := processor.PointerToPath(selector); err == nil {
+			selector = path
+		}
+	}
+	if selector == "" || selector == "." {
+		return nil
+	}
+	var stages []explainStage
+	quoted := strconv.Quote(group)
+	switch {
+	case m.exists && group == "true":
+		stages = append(stages, explainStage{"keep records that have " + selector, fmt.Sprintf("select(%s != null)", selector)})
+	case m.exists && group == "false":
+		stages = append(stages, explainStage{"keep records that do not have " + selector, fmt.Sprintf("select(%s == null)", selector)})
+	case m.exists:
+	case group == "*":
+		stages = append(stages, explainStage{"keep records where " + selector + " is present and not null or false", fmt.Sprintf("select(%s)", selector)})
+	case m.arrayGroups:
+		stages = append(stages, explainStage{fmt.Sprintf("keep records whose array %s contains %s", selector, quoted), fmt.Sprintf("select(%s|arrays|index([%s]))", selector, quoted)})
+	default:
+		stages = append(stages, explainStage{fmt.Sprintf("keep records whose %s equals %s", selector, quoted), fmt.Sprintf("select(%s==%s)", selector, quoted)})
+	}
+	for _, excluded := range m.excludedGroups {
+		stages = append(stages, explainStage{fmt.Sprintf("leave out records whose %s is %s", selector, strconv.Quote(excluded)), fmt.Sprintf("select(%s!=%s)", selector, strconv.Quote(excluded))})
+	}
+	return stages
+}
+
+// formatStages returns the stages that turn each record into the lines of the
+// output.
+func (m *Model) formatStages() []explainStage {
+	format := m.formatModel.Value()
+	var stages []explainStage
+	if m.hideNulls {
+		stages = append(stages, explainStage{"leave out results of the format that are null", "select(. != null)"})
+	}
+	switch fields := processor.FormatFields(format); {
+	case format == "" || format == ".":
+		text := "print each whole record, indented over several lines"
+		if m.oneLine {
+			text = "print each whole record as compact JSON on one line"
+		}
+		return append(stages, explainStage{text, "."})
+	case fields != nil:
+		stages = append(stages, explainStage{
+			"print the values of " + strings.Join(fields, ", ") + " joined with spaces",
+			fmt.Sprintf(`[%s]|join(" ")`, strings.Join(fields, ",")),
+		})
+	default:
+		stages = append(stages, explainStage{
+			"compute " + format + " for each record and print strings as they are and other values as compact JSON",
+			format,
+		})
+	}
+	if m.rawFallback {
+		stages = append(stages, explainStage{"print the record as compact JSON instead where the format gives nothing, only null or false, or an error", "(...)? // tojson"})
+	}
+	return stages
+}
+
+// explainContent returns the explanation formatted for the output window: the
+// jq command followed by its stages, numbered, each with the jq that does it.
+func (m *Model) explainContent() string {
+	faint := lipgloss.NewStyle().Faint(true)
+	lines := []string{"What the jq command does, stage by stage:", "", "  " + m.jq, ""}
+	for i, stage := range m.explainStages() {
+		lines = append(lines, fmt.Sprintf("%2d. %s", i+1, stage.text), "    "+faint.Render(stage.jq))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,185 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// groupsExportFile is the file, relative to the current directory, that the
+// save groups prompt suggests.
+const groupsExportFile = "jlv-groups.txt"
+
+// The prompts of the export window.
+const (
+	recordsExportPrompt = "Export records to> "
+	groupsExportPrompt  = "Save groups to> "
+)
+
+// copyGroups copies the current groups list to the clipboard, one group per
+// line. The result is reported in the footer.
+func (m *Model) copyGroups() tea.Cmd {
+	groups := m.exportGroups()
+	err := clipboard.WriteAll(strings.Join(groups, "\n") + "\n")
+	if err != nil {
+		return m.setStatus("copy groups: " + err.Error())
+	}
+	return m.setStatus(fmt.Sprintf("copied %d groups to the clipboard", len(groups)))
+}
+
+// saveGroups writes the current groups list to the given path, one group per
+// line. An existing file is not overwritten. The result, with the absolute
+// path of the file, is reported in the footer.
+func (m *Model) saveGroups(path string) tea.Cmd {
+	groups := m.exportGroups()
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	err := writeNewFile(path, []byte(strings.Join(groups, "\n")+"\n"))
+	if err != nil {
+		return m.setStatus("save groups: " + err.Error())
+	}
+	return m.setStatus(fmt.Sprintf("saved %d groups to %s", len(groups), path))
+}
+
+// writeNewFile writes the given content to a new file at the given path. It
+// returns an error if the file already exists.
+func writeNewFile(path string, content []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// exportGroups returns the sorted list of groups, excluding the "*" group.
+// When the shares or the spaced list are shown, each group is followed by a
+// tab and the number of records read for it.
+func (m *Model) exportGroups() []string {
+	var groups []string
+	for _, group := range slices.Sorted(maps.Keys(m.groups)) {
+		if group == "*" {
+			continue
+		}
+		if m.showShares || m.spacedGroups {
+			group = fmt.Sprintf("%s\t%d", group, m.groupCounts[group])
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// startRecordsExport focuses the prompt for the file that the records in the
+// output window are exported to.
+func (m *Model) startRecordsExport() tea.Cmd {
+	m.exportingGroups = false
+	return m.startExport(recordsExportPrompt, "")
+}
+
+// startGroupsExport focuses the prompt for the file that the groups list is
+// saved to, suggesting groupsExportFile.
+func (m *Model) startGroupsExport() tea.Cmd {
+	m.exportingGroups = true
+	return m.startExport(groupsExportPrompt, groupsExportFile)
+}
+
+// startExport focuses the export prompt with the given prompt and value.
+func (m *Model) startExport(prompt string, value string) tea.Cmd {
+	m.exportModel.Prompt = prompt
+	m.exportModel.Width = m.width - lipgloss.Width(prompt) - 2
+	m.exportModel.SetValue(value)
+	m.exportModel.CursorEnd()
+	return m.exportModel.Focus()
+}
+
+// handleExportMessage handles messages sent to the export prompt. Enter exports
+// the records, or saves the groups, to the entered file and esc cancels the
+// export. If the records of the current content were not loaded then the
+// content is reloaded and the export is completed when the processor reports
+// the new content.
+func (m *Model) handleExportMessage(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.exportModel.Blur()
+			return m, nil
+		case "enter":
+			m.exportModel.Blur()
+			path := strings.TrimSpace(m.exportModel.Value())
+			if path == "" {
+				return m, nil
+			}
+			if m.exportingGroups {
+				return m, m.saveGroups(path)
+			}
+			if m.recordsLoaded {
+				return m, m.saveRecords(path)
+			}
+			m.exportPath = path
+			return m, m.reloadContent
+		}
+	}
+	m.exportModel, cmd = m.exportModel.Update(normalizePaste(msg))
+	return m, cmd
+}
+
+// finishRecordsExport saves the records to the path of a pending export, if
+// there is one.
+func (m *Model) finishRecordsExport() tea.Cmd {
+	if m.exportPath == "" {
+		return nil
+	}
+	path := m.exportPath
+	m.exportPath = ""
+	return m.saveRecords(path)
+}
+
+// saveRecords writes the records shown in the output window to the given path.
+// If the path ends in .json then the records are written as a JSON array,
+// otherwise they are written as newline delimited JSON. The result is reported
+// in the footer.
+func (m *Model) saveRecords(path string) tea.Cmd {
+	records := m.exportRecords()
+	var content string
+	if filepath.Ext(path) == ".json" {
+		content = "[" + strings.Join(records, ",\n") + "]\n"
+	} else if len(records) > 0 {
+		content = strings.Join(records, "\n") + "\n"
+	}
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		return m.setStatus("export records: " + err.Error())
+	}
+	return m.setStatus(fmt.Sprintf("exported %d records to %s", len(records), path))
+}
+
+// exportRecords returns the compact JSON of each record shown in the output
+// window. Records that span several lines of output are only returned once.
+// Records that are not valid JSON, such as those interleaved with jq errors,
+// are skipped so that the export can be read back.
+func (m *Model) exportRecords() []string {
+	var records []string
+	var previous processor.Record
+	for idx, record := range m.rawOutputRecords {
+		if record == previous || !m.lineVisible(idx) || !json.Valid([]byte(record.JSON)) {
+			continue
+		}
+		records = append(records, record.JSON)
+		previous = record
+	}
+	return records
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSaveGroups(t *testing.T) {
+	m := newTestModel(80, 30, nil)
+	m.groups = map[string]struct{}{"*": {}, "info": {}, "error": {}}
+	m.countGroup("error")
+	m.countGroup("info")
+	m.countGroup("info")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "groups.txt")
+
+	save := func(path string) {
+		t.Helper()
+		m.startGroupsExport()
+		if got := m.exportModel.Value(); got != groupsExportFile {
+			t.Fatalf("suggested path = %q, want %q", got, groupsExportFile)
+		}
+		m.exportModel.SetValue(path)
+		m.handleExportMessage(tea.KeyMsg{Type: tea.KeyEnter})
+		if m.exportModel.Focused() {
+			t.Fatal("prompt still focused after enter")
+		}
+	}
+
+	save(dir + "/./groups.txt")
+	if want := "saved 2 groups to " + path; m.status != want {
+		t.Errorf("status = %q, want %q", m.status, want)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(content), "error\ninfo\n"; got != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+
+	m.showShares = true
+	save(path)
+	if !strings.HasPrefix(m.status, "save groups: ") || !strings.Contains(m.status, "exists") {
+		t.Errorf("status = %q, want an error for the existing file", m.status)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "error\ninfo\n" {
+		t.Errorf("existing file overwritten with %q", content)
+	}
+
+	countsPath := filepath.Join(dir, "counts.txt")
+	save(countsPath)
+	content, err = os.ReadFile(countsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(content), "error\t1\ninfo\t2\n"; got != want {
+		t.Errorf("content with shares = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,54 @@
+package model
+
+// formatCacheMargin is the number of lines above and below the output window
+// whose formatted rows are kept, so that scrolling back and forth does not
+// format them again.
+const formatCacheMargin = 100
+
+// cachedFormat is the formatted rows of a cached content line along with the
+// inputs they were formatted from.
+type cachedFormat struct {
+	opts   formatOptions
+	gutter string
+	line   string
+	rows   []string
+}
+
+// formatCached returns the cached content line at the given index, with the
+// given gutter and options, formatted by formatContentLine. The result for
+// each index is kept until pruneFormatCache forgets it, so that reformatting
+// the output window, as when a line is selected or new content arrives, only
+// formats the lines that changed. Any change to the inputs, like a new width,
+// formats the line again.
+func (m *Model) formatCached(idx int, opts formatOptions, gutter, line string) []string {
+	if cached, ok := m.formatCache[idx]; ok && cached.opts == opts && cached.gutter == gutter && cached.line == line {
+		return cached.rows
+	}
+	rows := formatContentLine(opts, gutter, line)
+	if rows == nil {
+		return nil
+	}
+	if m.formatCache == nil {
+		m.formatCache = map[int]cachedFormat{}
+	}
+	m.formatCache[idx] = cachedFormat{opts: opts, gutter: gutter, line: line, rows: rows}
+	return rows
+}
+
+// pruneFormatCache forgets the formatted rows of the lines that are more than
+// formatCacheMargin lines away from the lines of the output window between the
+// given positions in outputLines, so that the cache stays the size of a few
+// screens however much content is loaded.
+func (m *Model) pruneFormatCache(first, end int) {
+	if len(m.outputLines) == 0 {
+		m.formatCache = nil
+		return
+	}
+	low := m.outputLines[max(first-formatCacheMargin, 0)].idx
+	high := m.outputLines[min(end+formatCacheMargin, len(m.outputLines))-1].idx
+	for idx := range m.formatCache {
+		if idx < low || idx > high {
+			delete(m.formatCache, idx)
+		}
+	}
+}
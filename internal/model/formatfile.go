@@ -0,0 +1,57 @@
+package model
+
+import (
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// formatFilePollInterval is how often the format file is checked for changes.
+const formatFilePollInterval = time.Second
+
+// modTime returns the modification time of the given file, or the zero time if
+// it cannot be read.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// formatFileTick is a tea.Msg that checks the format file for changes.
+type formatFileTick struct{}
+
+// watchFormatFile returns the tea.Cmd that checks the format file for changes
+// after formatFilePollInterval, or nil if the format was not read from a file.
+func (m *Model) watchFormatFile() tea.Cmd {
+	if m.formatFile == "" {
+		return nil
+	}
+	return tea.Tick(formatFilePollInterval, func(time.Time) tea.Msg {
+		return formatFileTick{}
+	})
+}
+
+// handleFormatFileTick handles the formatFileTick message. If the format file
+// was modified since it was last read then it is read again and its format
+// replaces the one in the format window. A file that cannot be read is
+// reported in the footer and the format is kept.
+func (m *Model) handleFormatFileTick() (tea.Model, tea.Cmd) {
+	changed := modTime(m.formatFile)
+	if changed.IsZero() || changed.Equal(m.formatFileTime) {
+		return m, m.watchFormatFile()
+	}
+	m.formatFileTime = changed
+	format, err := processor.ReadFormatFile(m.formatFile)
+	if err != nil {
+		return m, tea.Batch(m.watchFormatFile(), m.setStatus("format file: "+err.Error()))
+	}
+	if format == m.formatModel.Value() {
+		return m, m.watchFormatFile()
+	}
+	m.formatModel.SetValue(format)
+	return m, tea.Batch(m.watchFormatFile(), m.reloadContent, m.setStatus("format file reloaded"))
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"hash/fnv"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// cachedGroupColor is the result of the last lookup made by lineColor.
+type cachedGroupColor struct {
+	record   processor.Record
+	selector string
+	color    lipgloss.Color
+}
+
+// lineColor returns the color of the group of the loaded content line at the
+// given index, or "" if the line is not colored. Lines are only colored while
+// all groups are shown and the selector is a path that can be evaluated in the
+// model. Lines without a record, or whose record lacks the selector, are not
+// colored.
+func (m *Model) lineColor(idx int) lipgloss.Color {
+	selector := m.selectorModel.Value()
+	if !m.groupColors || m.rawSelector || m.selectedGroup() != "*" || idx >= len(m.rawOutputRecords) {
+		return ""
+	}
+	if !m.pointer && !simplePathPattern.MatchString(selector) {
+		return ""
+	}
+	// Consecutive lines usually come from the same record, so the last
+	// result is reused rather than parsing the record again.
+	record := m.rawOutputRecords[idx]
+	if record == m.lastGroupColor.record && selector == m.lastGroupColor.selector {
+		return m.lastGroupColor.color
+	}
+	var color lipgloss.Color
+	if parsed, ok := parseRecord(record.JSON); ok {
+		value, found := m.lookupSelector(parsed, selector)
+		if m.exists {
+			color = groupColor(m.theme.palette, strconv.FormatBool(found))
+		} else if found {
+			color = groupColor(m.theme.palette, groupValue(value))
+		}
+	}
+	m.lastGroupColor = cachedGroupColor{record: record, selector: selector, color: color}
+	return color
+}
+
+// groupColor returns the color of the given group from the given palette. The
+// same group always gets the same color.
+func groupColor(palette []lipgloss.Color, group string) lipgloss.Color {
+	h := fnv.New32a()
+	h.Write([]byte(group))
+	return palette[h.Sum32()%uint32(len(palette))]
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// groupFollow is the policy by which a group is selected automatically as new
+// records are appended to the file.
+type groupFollow string
+
+// Possible group follow policies, in the order they are cycled through.
+const (
+	// groupFollowOff leaves the selected group alone.
+	groupFollowOff groupFollow = ""
+	// groupFollowNewest selects each group when its first record appears.
+	groupFollowNewest groupFollow = "newest"
+	// groupFollowBusiest selects the group with the most records.
+	groupFollowBusiest groupFollow = "busiest"
+)
+
+// cycleGroupFollow moves to the next group follow policy, or turns following
+// off, and selects the group the new policy follows right away.
+func (m *Model) cycleGroupFollow() tea.Cmd {
+	switch m.groupFollow {
+	case groupFollowOff:
+		m.groupFollow = groupFollowNewest
+	case groupFollowNewest:
+		m.groupFollow = groupFollowBusiest
+	default:
+		m.groupFollow = groupFollowOff
+	}
+	return m.followGroup("")
+}
+
+// followGroup selects the group the follow policy picks, given the group of a
+// new record that was not seen before, or "" if there is none. It returns the
+// tea.Cmd that shows the content of the group if the selection changed.
+func (m *Model) followGroup(newGroup string) tea.Cmd {
+	var group string
+	switch m.groupFollow {
+	case groupFollowNewest:
+		group = newGroup
+	case groupFollowBusiest:
+		group = m.busiestGroup()
+	}
+	if group == "" || group == m.selectedGroup() || !m.reselectGroup(item(group)) {
+		return nil
+	}
+	return m.groupChanged()
+}
+
+// busiestGroup returns the group with the most records read, or "" if there is
+// none. Ties go to the group that sorts first.
+func (m *Model) busiestGroup() string {
+	busiest := ""
+	for group := range m.groups {
+		if group == "*" {
+			continue
+		}
+		count, most := m.groupCounts[group], m.groupCounts[busiest]
+		if busiest == "" || count > most || (count == most && group < busiest) {
+			busiest = group
+		}
+	}
+	return busiest
+}
+
+// groupFollowStatus returns the follow policy for the footer, or "" if the
+// selected group is not followed.
+func (m *Model) groupFollowStatus() string {
+	if m.groupFollow == groupFollowOff {
+		return ""
+	}
+	return "following " + string(m.groupFollow) + " group"
+}
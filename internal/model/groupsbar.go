@@ -0,0 +1,90 @@
+package model
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// groupsLayout indicates how the groups window is laid out.
+type groupsLayout string
+
+// Possible groups layouts.
+const (
+	// groupsLayoutList shows the groups as a list to the left of the output.
+	groupsLayoutList groupsLayout = "list"
+	// groupsLayoutBar shows the groups as a single line above the output.
+	groupsLayoutBar groupsLayout = "bar"
+)
+
+// groupsBarHeight is the number of rows, including the border, taken by the
+// groups bar.
+const groupsBarHeight = 3
+
+// groupsView returns the view of the groups window rendered with the given
+// style in the current groups layout.
+func (m *Model) groupsView(style lipgloss.Style) string {
+	if m.groupsLayout == groupsLayoutBar {
+		return style.Width(m.width - 2).Render(m.groupsBarView(m.width - 2))
+	}
+	return style.Width(m.groupsModel.Width()).Render(m.groupsModel.View())
+}
+
+// groupsBarView returns the groups as a single line no wider than the given
+// width. The selected group is highlighted and the line is scrolled so that it
+// is visible.
+func (m *Model) groupsBarView(width int) string {
+	selectedStyle := lipgloss.NewStyle().Reverse(true)
+	items := m.groupsModel.VisibleItems()
+	selected := m.groupsModel.Index()
+	labels := make([]string, len(items))
+	for i, item := range items {
+		labels[i] = " " + m.groupLabel(item.FilterValue()) + " "
+	}
+	// Scroll right until the selected group fits.
+	start := 0
+	for start < selected && lipgloss.Width(strings.Join(labels[start:selected+1], "")) > width {
+		start++
+	}
+	var b strings.Builder
+	used := 0
+	for i := start; i < len(labels); i++ {
+		label := labels[i]
+		if used+lipgloss.Width(label) > width {
+			break
+		}
+		used += lipgloss.Width(label)
+		if i == selected {
+			label = selectedStyle.Render(label)
+		}
+		b.WriteString(label)
+	}
+	return b.String()
+}
+
+// handleGroupsBarMessage handles messages sent to the groups window when it is
+// laid out as a bar. Left and right select the previous and next group. If the
+// selection changed then a command is sent to the processor to re-start
+// watching the file for content.
+func (m *Model) handleGroupsBarMessage(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	origIndex := m.groupsModel.Index()
+	switch keyMsg.String() {
+	case "left", "h":
+		m.groupsModel.Select(max(origIndex-1, 0))
+	case "right", "l":
+		m.groupsModel.Select(min(origIndex+1, len(m.groupsModel.VisibleItems())-1))
+	case "home":
+		m.groupsModel.Select(0)
+	case "end":
+		m.groupsModel.Select(len(m.groupsModel.VisibleItems()) - 1)
+	}
+	if m.groupsModel.Index() == origIndex {
+		return m, nil
+	}
+	return m, m.groupChanged()
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// groupShareWidth is the widest share shown after a group, like " 100%".
+const groupShareWidth = len(" 100%")
+
+// groupDelegate is the list.ItemDelegate of the groups window. It shows each
+// group as labeled by groupLabel, described by groupDescription when
+// descriptions are shown.
+type groupDelegate struct {
+	list.DefaultDelegate
+	m *Model
+}
+
+// Render implements list.ItemDelegate.
+func (d groupDelegate) Render(w io.Writer, l list.Model, index int, listItem list.Item) {
+	if group, ok := listItem.(item); ok {
+		listItem = describedItem{item: item(d.m.groupLabel(string(group))), description: d.m.groupDescription(string(group))}
+	}
+	d.DefaultDelegate.Render(w, l, index, listItem)
+}
+
+// countGroup counts a record of the given group towards the shares of the
+// groups.
+func (m *Model) countGroup(group string) {
+	m.groupCounts[group]++
+	m.groupsTotal++
+}
+
+// resetGroupCounts forgets the records counted by countGroup.
+func (m *Model) resetGroupCounts() {
+	m.groupCounts = map[string]int{}
+	m.groupsTotal = 0
+}
+
+// groupLabel returns the given group as it is shown in the groups window.
+// Excluded groups are preceded by excludedMarker. When shares are shown, groups
+// other than "*" are followed by the percentage of the records read for the
+// groups that are in the group, like "error 12%".
+func (m *Model) groupLabel(group string) string {
+	if slices.Contains(m.excludedGroups, group) {
+		return excludedMarker + m.groupShare(group)
+	}
+	return m.groupShare(group)
+}
+
+// groupShare returns the given group followed by its share of the records when
+// shares are shown.
+func (m *Model) groupShare(group string) string {
+	if !m.showShares || group == "*" || m.groupsTotal == 0 {
+		return group
+	}
+	count := m.groupCounts[group]
+	percent := count * 100 / m.groupsTotal
+	if count > 0 && percent == 0 {
+		return group + " <1%"
+	}
+	return fmt.Sprintf("%s %d%%", group, percent)
+}
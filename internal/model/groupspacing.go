@@ -0,0 +1,42 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// newGroupDelegate returns the delegate of the groups window. Groups are
+// listed one per row unless the list is spaced, in which case each group is
+// followed by its number of records and separated from the next by a blank
+// row.
+func (m *Model) newGroupDelegate() groupDelegate {
+	delegate := groupDelegate{DefaultDelegate: list.NewDefaultDelegate(), m: m}
+	if !m.spacedGroups {
+		delegate.ShowDescription = false
+		delegate.SetSpacing(0) // compact lists
+	}
+	return delegate
+}
+
+// toggleGroupSpacing switches the groups window between the compact and the
+// spaced list.
+func (m *Model) toggleGroupSpacing() {
+	m.spacedGroups = !m.spacedGroups
+	m.groupsModel.SetDelegate(m.newGroupDelegate())
+	m.updateGroupWidth()
+}
+
+// groupDescription returns the description shown below the given group in the
+// spaced list: the number of records read for the groups that are in it, or
+// all of them for "*".
+func (m *Model) groupDescription(group string) string {
+	count := m.groupCounts[group]
+	if group == "*" {
+		count = m.groupsTotal
+	}
+	if count == 1 {
+		return "1 record"
+	}
+	return fmt.Sprintf("%d records", count)
+}
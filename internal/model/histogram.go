@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// openHistogram shows the histogram of the numeric values produced by the
+// format of the selected records in place of the output.
+func (m *Model) openHistogram() tea.Cmd {
+	if m.showTimeline {
+		m.closeTimeline()
+	}
+	m.showHistogram = true
+	m.histogram = processor.Histogram{}
+	m.updateOutputModelContent()
+	return m.runHistogram
+}
+
+// closeHistogram hides the histogram and restores the content of the output
+// window.
+func (m *Model) closeHistogram() {
+	m.showHistogram = false
+	m.updateOutputModelContent()
+}
+
+// handleProcessorHistogram handles the processor.Histogram message. This
+// message conveys the bucketed values of the format, or the error from jq if it
+// failed. It is shown in the output window while the histogram is open.
+func (m *Model) handleProcessorHistogram(msg processor.Histogram) (tea.Model, tea.Cmd) {
+	if m.threshold.ranging {
+		return m, m.handleThresholdRange(msg)
+	}
+	if !m.showHistogram {
+		return m, nil
+	}
+	m.histogram = msg
+	m.outputModel.GotoTop()
+	m.updateOutputModelContent()
+	return m, nil
+}
+
+// histogramContent returns the histogram as one row per bucket, each made of
+// the range of the bucket, a bar scaled to the largest bucket, and the count.
+func (m *Model) histogramContent() string {
+	switch {
+	case m.histogram.Err != nil:
+		return m.histogram.Err.Error() + "\n" + m.histogram.Message
+	case m.histogram.Jq == "":
+		return "Running..."
+	case len(m.histogram.Counts) == 0:
+		return "(no numeric values)"
+	}
+	counts := m.histogram.Counts
+	if m.histogram.Max == m.histogram.Min {
+		// Every value is in the first bucket.
+		counts = counts[:1]
+	}
+	width := (m.histogram.Max - m.histogram.Min) / float64(len(counts))
+	labels := make([]string, len(counts))
+	labelWidth, countWidth, maxCount := 0, 0, 0
+	for i, count := range counts {
+		low := m.histogram.Min + float64(i)*width
+		labels[i] = fmt.Sprintf("%.4g - %.4g", low, low+width)
+		labelWidth = max(labelWidth, len(labels[i]))
+		countWidth = max(countWidth, len(fmt.Sprint(count)))
+		maxCount = max(maxCount, count)
+	}
+	barWidth := m.outputModel.Width - labelWidth - countWidth - 4
+	var lines []string
+	for i, count := range counts {
+		bar := ""
+		if maxCount > 0 && barWidth > 0 {
+			bar = strings.Repeat("█", count*barWidth/maxCount)
+		}
+		lines = append(lines, fmt.Sprintf("%*s │%-*s %*d", labelWidth, labels[i], max(barWidth, 0), bar, countWidth, count))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runHistogram is a tea.Cmd that issues a processor.RunHistogramOperation for
+// the selected group and format to the currently connected processor. It
+// returns no message.
+func (m *Model) runHistogram() tea.Msg {
+	m.processorCmdChan <- processor.Command{
+		Operation:      processor.RunHistogramOperation,
+		Selector:       m.selectorModel.Value(),
+		Format:         m.formatModel.Value(),
+		Group:          m.selectedGroup(),
+		ExcludedGroups: m.excludedGroups,
+		Path:           m.path,
+		RawSelector:    m.rawSelector,
+		Exists:         m.exists,
+		ArrayGroups:    m.arrayGroups,
+		Pointer:        m.pointer,
+		Redact:         m.redact,
+		Buckets:        m.buckets,
+		NoEnv:          m.noEnv,
+		TimeField:      m.timeField,
+		Since:          m.since,
+		Until:          m.until,
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultEditor is the command used to open records when neither $VISUAL nor
+// $EDITOR is set.
+const defaultEditor = "vi"
+
+// editorFinished is a tea.Msg that indicates the editor command has exited.
+// The temp file holding the record is removed.
+type editorFinished struct {
+	path string
+	err  error
+}
+
+// currentRecord returns the indented JSON of the record that produced the line
+// at the top of the output window. If records are not loaded then the content
+// is reloaded with records and ok is false along with the tea.Cmd to do so.
+func (m *Model) currentRecord() (record string, cmd tea.Cmd, ok bool) {
+	if !m.recordsLoaded {
+		m.inspectRecords = true
+		return "", tea.Batch(m.reloadContent, m.setStatus("loading records, press again once loaded")), false
+	}
+	current := m.topOutputLine()
+	if current < 0 || m.rawOutputRecords[current].JSON == "" {
+		return "", m.setStatus("no record"), false
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte(m.rawOutputRecords[current].JSON), "", "  "); err != nil {
+		return "", m.setStatus("record: " + err.Error()), false
+	}
+	return indented.String(), nil, true
+}
+
+// openRecordInEditor returns a tea.Cmd that writes the record at the top of the
+// output window to a temp file and suspends the application to open it in the
+// editor command ($VISUAL, $EDITOR, or vi). The command is run by the shell so
+// that it may include arguments.
+func (m *Model) openRecordInEditor() tea.Cmd {
+	record, cmd, ok := m.currentRecord()
+	if !ok {
+		return cmd
+	}
+	file, err := os.CreateTemp("", "jlv-record-*.json")
+	if err != nil {
+		return m.setStatus("editor: " + err.Error())
+	}
+	_, err = file.WriteString(record + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return m.setStatus("editor: " + err.Error())
+	}
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = defaultEditor
+	}
+	editorCmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", file.Name())
+	return tea.ExecProcess(editorCmd, func(err error) tea.Msg {
+		return editorFinished{path: file.Name(), err: err}
+	})
+}
+
+// handleEditorFinished handles the editorFinished message. The temp file is
+// removed and the window size is re-queried since the terminal may have been
+// resized while the editor was running. Any error from the editor is reported
+// in the footer.
+func (m *Model) handleEditorFinished(msg editorFinished) (tea.Model, tea.Cmd) {
+	os.Remove(msg.path)
+	if msg.err != nil {
+		return m, tea.Batch(tea.WindowSize(), m.setStatus("editor: "+msg.err.Error()))
+	}
+	return m, tea.WindowSize()
+}
+
+// copyRecordCommand copies a ready to run jq command line that pipes the record
+// at the top of the output window into jq to the clipboard. The result is
+// reported in the footer.
+func (m *Model) copyRecordCommand() tea.Cmd {
+	record, cmd, ok := m.currentRecord()
+	if !ok {
+		return cmd
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(record)); err != nil {
+		return m.setStatus("copy record: " + err.Error())
+	}
+	if err := clipboard.WriteAll("echo " + shellQuote(compact.String()) + " | jq ."); err != nil {
+		return m.setStatus("copy record: " + err.Error())
+	}
+	return m.setStatus("copied a jq command for the record to the clipboard")
+}
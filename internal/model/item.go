@@ -23,3 +23,14 @@ func (i item) Title() string {
 func (i item) Description() string {
 	return string(i)
 }
+
+// describedItem is an item with a description other than its title.
+type describedItem struct {
+	item
+	description string
+}
+
+// Description returns the description to display for this item in a list.
+func (i describedItem) Description() string {
+	return i.description
+}
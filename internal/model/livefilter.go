@@ -0,0 +1,127 @@
+package model
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// simplePathPattern matches selectors that lookupField can evaluate, like
+// ".level" or ".properties.logger".
+var simplePathPattern = regexp.MustCompile(`^(\.[A-Za-z_][A-Za-z0-9_]*)+$`)
+
+// liveFiltering returns true if groups are selected by filtering the loaded
+// records in the model rather than by reloading the content. This requires the
+// live groups mode and a selector that lookupField can evaluate.
+func (m *Model) liveFiltering() bool {
+	return m.liveGroups && !m.rawSelector && simplePathPattern.MatchString(m.selectorModel.Value())
+}
+
+// selectedGroup returns the group selected in the groups window, or "*" if
+// there is none.
+func (m *Model) selectedGroup() string {
+	if selectedItem := m.groupsModel.SelectedItem(); selectedItem != nil {
+		return selectedItem.FilterValue()
+	}
+	return "*"
+}
+
+// groupChanged returns the tea.Cmd that shows the content of the newly
+// selected group. When live filtering, the loaded content is filtered again
+// and only the context view, if open, is refreshed. Otherwise the content is
+// reloaded.
+func (m *Model) groupChanged() tea.Cmd {
+	m.updateGroupsTitle()
+	if m.liveFiltering() {
+		m.applied.group = m.selectedGroup()
+		m.updateOutputModelContent()
+		return m.refreshContext()
+	}
+	return m.reloadContent
+}
+
+// lineVisible returns true if the loaded content line at the given index
+// belongs to the selected group. All lines are visible unless live filtering.
+// Lines without a record, like errors from jq, are always visible.
+func (m *Model) lineVisible(idx int) bool {
+	group := m.selectedGroup()
+	if group == "*" || !m.liveFiltering() || idx >= len(m.rawOutputRecords) {
+		return true
+	}
+	// Consecutive lines usually come from the same record, so the last
+	// result is reused rather than parsing the record again.
+	key := visibility{record: m.rawOutputRecords[idx], group: group, selector: m.selectorModel.Value()}
+	if key == m.lastVisibility.key {
+		return m.lastVisibility.visible
+	}
+	visible := true
+	if record, ok := parseRecord(key.record.JSON); ok {
+		value, found := m.lookupSelector(record, key.selector)
+		visible = groupMatches(group, value, found, m.exists, m.arrayGroups)
+	}
+	m.lastVisibility = cachedVisibility{key: key, visible: visible}
+	return visible
+}
+
+// lookupSelector returns the value at the given selector in the given parsed
+// record, reading the selector as a JSON pointer in pointer mode.
+func (m *Model) lookupSelector(record any, selector string) (any, bool) {
+	if !m.pointer {
+		return lookupField(record, selector)
+	}
+	keys, err := processor.PointerTokens(selector)
+	if err != nil {
+		return nil, false
+	}
+	return lookupKeys(record, keys)
+}
+
+// visibility identifies a record and the group and selector it was checked
+// against by lineVisible.
+type visibility struct {
+	record   processor.Record
+	group    string
+	selector string
+}
+
+// cachedVisibility is the result of the last check made by lineVisible.
+type cachedVisibility struct {
+	key     visibility
+	visible bool
+}
+
+// groupMatches returns true if a record whose selector has the given value
+// belongs to the given group. The found flag reports whether the selector
+// exists in the record. The exists and array flags match the grouping modes of
+// the same names.
+func groupMatches(group string, value any, found, exists, array bool) bool {
+	if exists {
+		return group == strconv.FormatBool(found)
+	}
+	if !found {
+		return false
+	}
+	if elements, ok := value.([]any); ok && array {
+		return slices.ContainsFunc(elements, func(element any) bool {
+			return groupValue(element) == group
+		})
+	}
+	return groupValue(value) == group
+}
+
+// groupValue returns the given value as jq -r would print it, which is how
+// groups are named.
+func groupValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// jumpToMark scrolls the output window so that the next, or previous, record
+// matching the mark predicate is at the top, wrapping around at the ends of the
+// output. The search starts from the last record jumped to while it is still
+// visible, so that repeated jumps move on even when the record could not be
+// scrolled to the top, and from the top of the output window otherwise.
+func (m *Model) jumpToMark(forward bool) tea.Cmd {
+	if m.markPredicate == "" {
+		return m.setStatus("no mark predicate, start with --mark")
+	}
+	var rows []int
+	for _, line := range m.outputLines {
+		// Only the first line of each record is a jump target.
+		if idx := line.idx; idx < len(m.rawOutputRecords) {
+			if record := m.rawOutputRecords[idx]; record.Marked && (idx == 0 || m.rawOutputRecords[idx-1] != record) {
+				rows = append(rows, line.start)
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return m.setStatus("no marked records")
+	}
+	from := m.outputYOffset()
+	if m.markRow >= from && m.markRow < from+m.outputModel.Height {
+		from = m.markRow
+	}
+	var target int
+	if forward {
+		for i, row := range rows {
+			if row > from {
+				target = i
+				break
+			}
+		}
+	} else {
+		target = len(rows) - 1
+		for i := len(rows) - 1; i >= 0; i-- {
+			if rows[i] < from {
+				target = i
+				break
+			}
+		}
+	}
+	m.markRow = rows[target]
+	m.setOutputYOffset(m.markRow)
+	return m.setStatus(fmt.Sprintf("marked record %d of %d", target+1, len(rows)))
+}
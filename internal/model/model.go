@@ -3,11 +3,15 @@ package model
 import (
 	"fmt"
 	"maps"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/cursor"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -30,6 +34,19 @@ const (
 	outputWindow
 )
 
+// gutterMode indicates what is shown in the gutter of the output window.
+type gutterMode int
+
+// Possible gutter modes, in the order they are cycled through.
+const (
+	gutterNone gutterMode = iota
+	gutterLineNumbers
+	gutterSourceLines
+	gutterOffsets
+	gutterRecordIDs
+	gutterDeltas
+)
+
 // Model holds the state of the application.
 type Model struct {
 	selectorModel    textinput.Model
@@ -39,63 +56,414 @@ type Model struct {
 	selectedWindow   selectedWindowIndex
 	groups           map[string]struct{}
 	rawOutputContent []string
-	outputContent    []string
+	rawOutputRecords []processor.Record
+	outputLines      []outputLine
+	formatCache      map[int]cachedFormat
+	outputOffset     int
 	path             string
 	jq               string
 	zoomed           bool
+	minimal          bool
 	wrap             bool
-	lineNumbers      bool
+	gutter           gutterMode
 	width            int
 	height           int
 	atBottom         bool
 	processorCmdChan chan<- processor.Command
 	contentStopped   bool
 	groupsStopped    bool
+	status           string
+	statusID         int
+	maxGroups        int
+	groupsTruncated  bool
+	rawSelector      bool
+	dedup            bool
+	pagerCommand     string
+	selectorInvalid  bool
+	tabWidth         int
+	relaxed          bool
+	sortKeys         bool
+	indent           int
+	tab              bool
+	lenient          bool
+	diffView         bool
+	recordsLoaded    bool
+	timeField        string
+	since            time.Time
+	until            time.Time
+	timeRange        timeRange
+	lastTimeRecord   processor.Record
+	recordTimes      [2]cachedTimestamp
+	lag              int
+	groupsWidth      int
+	fromLine         int
+	lastLines        int
+	firstLine        int
+	loadingEarlier   bool
+	groupsLayout     groupsLayout
+	groupFollow      groupFollow
+	sanitize         bool
+	exportModel      textinput.Model
+	exportPath       string
+	exportingGroups  bool
+	recordJumpModel  textinput.Model
+	recordJumpID     string
+	lastRecordID     cachedRecordID
+	spinner          spinner.Model
+	refreshingGroups bool
+	readingGroups    bool
+	spinning         bool
+	spacedGroups     bool
+	colorJSON        bool
+	severity         severityCounts
+	pendingGroup     string
+	redact           []string
+	alertPredicate   string
+	markPredicate    string
+	markRow          int
+	threshold        threshold
+	requested        appliedQuery
+	theme            theme
+	applied          appliedQuery
+	lastAlert        time.Time
+	alertID          int
+	endedAlertID     int
+	exists           bool
+	maxLineBytes     int
+	showSummary      bool
+	arrayGroups      bool
+	scratchModel     textinput.Model
+	scratch          bool
+	scratchOutput    []string
+	scratchJq        string
+	wrapIndent       string
+	liveGroups       bool
+	lastVisibility   cachedVisibility
+	groupColors      bool
+	lastGroupColor   cachedGroupColor
+	idleTimeout      time.Duration
+	idleID           int
+	encoding         string
+	nulDelimited     bool
+	showHistogram    bool
+	histogram        processor.Histogram
+	groupCounts      map[string]int
+	groupsTotal      int
+	showShares       bool
+	excludedGroups   []string
+	showTimeline     bool
+	timeline         processor.Timeline
+	timelineBuckets  int
+	showContext      bool
+	selection        selection
+	showExamples     bool
+	showExplain      bool
+	examplesTarget   selectedWindowIndex
+	examplesIndex    int
+	loading          bool
+	waitingFor       string
+	loadingID        int
+	context          contextState
+	buckets          int
+	inspectRecords   bool
+	throttle         int
+	contentErr       error
+	groupsErr        error
+	browsing         bool
+	browsePaths      []string
+	browseCrumbs     []string
+	browseIndex      int
+	browseSelect     string
+	pointer          bool
+	showTail         bool
+	tailLines        []string
+	tailHeight       int
+	noEnv            bool
+	source           string
+	recordDelimiter  string
+	reloadDebounce   time.Duration
+	reloadIDs        map[selectedWindowIndex]int
+	pinnedGroups     []string
+	oneLine          bool
+	hideNulls        bool
+	rawFallback      bool
+	formatFile       string
+	formatFileTime   time.Time
+}
+
+// minGroupsWidth and minOutputWidth are the narrowest the groups and output
+// windows can be made when resizing the groups window.
+const (
+	minGroupsWidth = 5
+	minOutputWidth = 20
+)
+
+// waitingPlaceholder is shown in the output window while no content has been
+// read, as when the watched file is empty.
+const waitingPlaceholder = "waiting for data…"
+
+// lagThreshold is how many streamed lines may wait to be rendered before the
+// footer reports that the output is behind.
+const lagThreshold = 100
+
+// statusTimeout is how long a status message is shown in the footer.
+const statusTimeout = 3 * time.Second
+
+// idleTimeout is a tea.Msg that exits the application if no content has
+// arrived since the idle timer with the given id was started.
+type idleTimeout struct {
+	id int
+}
+
+// loadingDelay is how long reloaded content may take to arrive before the
+// output window is cleared to show a loading placeholder. Fast reloads replace
+// the output directly so that it does not flash.
+const loadingDelay = 150 * time.Millisecond
+
+// contentRequested is a tea.Msg that indicates content with the given loading
+// id has been requested from the processor.
+type contentRequested struct {
+	id int
+}
+
+// showLoading is a tea.Msg that shows the loading placeholder if the content
+// with the given loading id has still not arrived.
+type showLoading struct {
+	id int
+}
+
+// clearStatus is a tea.Msg that clears the status message with the given id
+// from the footer if it has not already been replaced.
+type clearStatus struct {
+	id int
 }
 
 // ModelOpts defines the options that can be set on a Model.
 type ModelOpts struct {
-	Selector    string
-	Output      string
-	Path        string
-	LineNumbers bool
-	Wrap        bool
+	Selector          string
+	Output            string
+	Path              string
+	Source            string
+	Group             string
+	LineNumbers       bool
+	Wrap              bool
+	MaxGroups         int
+	RawSelector       bool
+	TimeField         string
+	Since             time.Time
+	Until             time.Time
+	Dedup             bool
+	Pager             string
+	TabWidth          int
+	Relaxed           bool
+	SortKeys          bool
+	Indent            int
+	Tab               bool
+	DiffView          bool
+	Lenient           bool
+	FromLine          int
+	LastLines         int
+	GroupsLayout      string
+	FollowGroup       string
+	Sanitize          bool
+	SeverityField     string
+	SeverityLevels    []string
+	Redact            []string
+	Alert             string
+	Mark              string
+	ThresholdField    string
+	Exists            bool
+	MaxLineBytes      int
+	WrapIndent        string
+	LiveGroups        bool
+	IdleTimeout       time.Duration
+	Encoding          string
+	NulDelimited      bool
+	Buckets           int
+	Throttle          int
+	Pointer           bool
+	RawTail           bool
+	NoEnv             bool
+	GroupColors       bool
+	Minimal           bool
+	TimeDeltas        bool
+	SourceLines       bool
+	RecordIDs         bool
+	ThemeFromTerminal bool
+	StdinMaxBytes     int64
+	RecordDelimiter   string
+	ReloadDebounce    time.Duration
+	PinGroups         []string
+	OneLine           bool
+	HideNulls         bool
+	RawFallback       bool
+	ColorJSON         bool
+	// FormatFile is the file the format in Output was read from. The format
+	// is read again when the file changes.
+	FormatFile string
+}
+
+// selectorPrompt returns the prompt of the selector window for the given
+// selector modes.
+func selectorPrompt(rawSelector, exists, pointer bool) string {
+	kind := "path"
+	if pointer {
+		kind = "pointer"
+	}
+	switch {
+	case rawSelector:
+		return "Filter> "
+	case exists:
+		return "Group by existence of " + kind + "> "
+	}
+	return "Group by " + kind + "> "
+}
+
+// plausibleSelector returns false if the given selector would certainly be
+// rejected: an invalid JSON pointer in pointer mode, or an implausible jq
+// expression otherwise.
+func (m *Model) plausibleSelector(selector string) bool {
+	if m.pointer && !m.rawSelector {
+		_, err := processor.PointerTokens(selector)
+		return err == nil
+	}
+	return plausibleExpression(selector)
+}
+
+// selectorSyntax returns the given dotted jq path as it is written in the
+// selector: a JSON pointer in pointer mode, or the path itself otherwise.
+func (m *Model) selectorSyntax(path string) string {
+	if m.pointer {
+		return processor.PathToPointer(path)
+	}
+	return path
 }
 
 // NewModel returns a new Model configured with the given ModelOpts.
 func NewModel(opts ModelOpts) *Model {
 	m := &Model{}
+	m.rawSelector = opts.RawSelector
+	m.pointer = opts.Pointer
 	m.selectorModel = textinput.New()
-	m.selectorModel.Prompt = "Group by path> "
+	m.selectorModel.Prompt = selectorPrompt(opts.RawSelector, opts.Exists, opts.Pointer)
 	m.selectorModel.Cursor.SetMode(cursor.CursorStatic)
 	m.selectorModel.SetValue(opts.Selector)
+	m.selectorInvalid = !m.plausibleSelector(opts.Selector)
 	m.formatModel = textinput.New()
 	m.formatModel.Prompt = "Output format> "
 	m.formatModel.Cursor.SetMode(cursor.CursorStatic)
 	m.formatModel.SetValue(opts.Output)
-	delegate := list.NewDefaultDelegate()
-	delegate.ShowDescription = false
-	delegate.SetSpacing(0) // compact lists
+	m.resetGroupCounts()
 	m.groups = map[string]struct{}{}
 	m.groups["*"] = struct{}{}
-	m.groupsModel = list.New(getGroupItems(m.groups), delegate, 10, 20)
-	m.groupsModel.Title = "groups"
+	m.groupsModel = list.New(getGroupItems(m.groups, m.pinnedGroups), m.newGroupDelegate(), 10, 20)
+	m.groupsModel.Title = "groups (0)"
+	m.groupsModel.SetWidth(m.groupsFitWidth())
 	m.groupsModel.SetShowHelp(false)
-	m.groupsModel.SetShowTitle(false)
 	m.groupsModel.SetShowStatusBar(false)
 	m.outputModel = viewport.New(0, 0)
+	m.spinner = spinner.New(spinner.WithSpinner(spinner.MiniDot))
+	m.scratchModel = textinput.New()
+	m.scratchModel.Prompt = "jq> "
+	m.scratchModel.Cursor.SetMode(cursor.CursorStatic)
+	m.exportModel = textinput.New()
+	m.exportModel.Prompt = recordsExportPrompt
+	m.exportModel.Cursor.SetMode(cursor.CursorStatic)
+	m.recordJumpModel = textinput.New()
+	m.recordJumpModel.Prompt = "Jump to record ID> "
+	m.recordJumpModel.Cursor.SetMode(cursor.CursorStatic)
 	m.path = opts.Path
-	m.lineNumbers = opts.LineNumbers
+	m.source = opts.Source
+	m.recordDelimiter = opts.RecordDelimiter
+	m.pendingGroup = opts.Group
+	m.theme = darkTheme
+	if opts.ThemeFromTerminal {
+		m.theme = terminalTheme()
+	}
+	if opts.TimeDeltas && opts.TimeField != "" {
+		m.gutter = gutterDeltas
+	} else if opts.SourceLines {
+		m.gutter = gutterSourceLines
+	} else if opts.RecordIDs {
+		m.gutter = gutterRecordIDs
+	} else if opts.LineNumbers {
+		m.gutter = gutterLineNumbers
+	}
 	m.wrap = opts.Wrap
+	m.maxGroups = opts.MaxGroups
+	m.pinnedGroups = opts.PinGroups
+	m.timeField = opts.TimeField
+	m.since = opts.Since
+	m.until = opts.Until
+	m.dedup = opts.Dedup
+	m.pagerCommand = opts.Pager
+	m.tabWidth = opts.TabWidth
+	m.relaxed = opts.Relaxed
+	m.sortKeys = opts.SortKeys
+	m.indent = opts.Indent
+	m.tab = opts.Tab
+	m.lenient = opts.Lenient
+	m.diffView = opts.DiffView
+	m.fromLine = opts.FromLine
+	m.lastLines = opts.LastLines
+	m.groupsLayout = groupsLayout(opts.GroupsLayout)
+	m.groupFollow = groupFollow(opts.FollowGroup)
+	m.sanitize = opts.Sanitize
+	m.severity = newSeverityCounts(opts.SeverityField, opts.SeverityLevels)
+	m.redact = opts.Redact
+	m.alertPredicate = opts.Alert
+	m.markPredicate = opts.Mark
+	m.threshold.field = opts.ThresholdField
+	m.markRow = -1
+	m.exists = opts.Exists
+	m.maxLineBytes = opts.MaxLineBytes
+	m.wrapIndent = opts.WrapIndent
+	m.liveGroups = opts.LiveGroups
+	m.idleTimeout = opts.IdleTimeout
+	m.reloadDebounce = opts.ReloadDebounce
+	m.reloadIDs = map[selectedWindowIndex]int{}
+	m.encoding = opts.Encoding
+	m.nulDelimited = opts.NulDelimited
+	m.oneLine = opts.OneLine
+	m.hideNulls = opts.HideNulls
+	m.rawFallback = opts.RawFallback
+	m.colorJSON = opts.ColorJSON
+	m.formatFile = opts.FormatFile
+	m.formatFileTime = modTime(opts.FormatFile)
+	m.buckets = opts.Buckets
+	m.throttle = opts.Throttle
+	m.showTail = opts.RawTail
+	m.minimal = opts.Minimal
+	if m.minimal {
+		m.selectedWindow = outputWindow
+	}
+	m.noEnv = opts.NoEnv
+	m.groupColors = opts.GroupColors
+	m.context.size = defaultContextSize
 	m.atBottom = true
 	return m
 }
 
-// Init initializes the application. It focuses on the selector element.
+// Init initializes the application. It focuses on the selector element unless
+// the application starts in the minimal layout, where the output window has
+// focus.
 func (m *Model) Init() tea.Cmd {
+	if m.minimal {
+		return tea.Batch(tea.SetWindowTitle("jlv "+m.path), m.watchFormatFile())
+	}
 	return tea.Batch(
 		tea.SetWindowTitle("jlv "+m.path),
-		m.selectorModel.Focus())
+		m.selectorModel.Focus(),
+		m.watchFormatFile())
+}
+
+// Err returns the error that prevented the content or groups from being read,
+// if that error was not resolved before the application exited.
+func (m *Model) Err() error {
+	if m.contentErr != nil {
+		return m.contentErr
+	}
+	return m.groupsErr
 }
 
 // Update handles messages.
@@ -104,6 +472,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case processor.CommandChannel:
 		return m.handleCommandChannel(msg)
+	case processor.EarlierContent:
+		return m.handleProcessorEarlierContent(msg)
 	case processor.ContentStart:
 		return m.handleProcessorContentStart(msg)
 	case processor.ContentError:
@@ -130,15 +500,86 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	case processor.JQCommand:
 		return m.handleProcessorJQCommand(msg)
+	case processor.LinesTruncated:
+		return m, m.setStatus(fmt.Sprintf("truncated lines longer than %d bytes", msg.MaxBytes))
+	case processor.ScratchResult:
+		return m.handleProcessorScratchResult(msg)
+	case processor.Histogram:
+		return m.handleProcessorHistogram(msg)
+	case processor.Timeline:
+		return m.handleProcessorTimeline(msg)
+	case processor.ContextLines:
+		return m.handleProcessorContextLines(msg)
+	case processor.WaitingForFile:
+		return m.handleProcessorWaitingForFile(msg)
+	case processor.PathList:
+		return m.handleProcessorPathList(msg)
+	case processor.TailStart:
+		return m.handleProcessorTailStart(msg)
+	case processor.TailLine:
+		return m.handleProcessorTailLine(msg)
+	case processor.TailError:
+		return m.handleProcessorTailError(msg)
+	case processor.SelectorSuggestion:
+		return m.handleProcessorSelectorSuggestion(msg)
+	case pagerFinished:
+		return m.handlePagerFinished(msg)
+	case editorFinished:
+		return m.handleEditorFinished(msg)
+	case spinner.TickMsg:
+		return m.handleSpinnerTick(msg)
+	case contentRequested:
+		return m, tea.Batch(m.startSpinner(), tea.Tick(loadingDelay, func(time.Time) tea.Msg {
+			return showLoading{id: msg.id}
+		}))
+	case groupsRequested:
+		return m, m.startSpinner()
+	case debouncedReload:
+		return m.handleDebouncedReload(msg)
+	case formatFileTick:
+		return m.handleFormatFileTick()
+	case showLoading:
+		if m.loading && msg.id == m.loadingID {
+			m.showLoadingPlaceholder()
+		}
+		return m, nil
+	case idleTimeout:
+		if msg.id == m.idleID {
+			m.stopProcessor()
+		}
+		return m, nil
+	case endAlertFlash:
+		m.endedAlertID = msg.id
+		return m, nil
+	case clearStatus:
+		if msg.id == m.statusID {
+			m.status = ""
+		}
+		return m, nil
 	case tea.WindowSizeMsg:
 		return m.handleWindowSize(msg)
 	case tea.KeyMsg:
+		if m.exportModel.Focused() {
+			return m.handleExportMessage(msg)
+		}
+		if m.recordJumpModel.Focused() {
+			return m.handleRecordJumpMessage(msg)
+		}
+		if m.scratch {
+			return m.handleScratchMessage(msg)
+		}
+		if m.browsing {
+			return m.handleBrowseMessage(msg)
+		}
+		if m.showExamples {
+			return m.handleExamplesMessage(msg)
+		}
 		newModel, cmd, handled := m.handleGlobalKey(msg)
 		if handled {
 			return newModel, cmd
 		}
 	}
-	if m.zoomed {
+	if m.zoomed || m.minimal {
 		return m.handleOutputMessage(msg)
 	}
 	switch m.selectedWindow {
@@ -147,6 +588,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case formatWindow:
 		return m.handleFormatMessage(msg)
 	case groupsWindow:
+		if m.groupsLayout == groupsLayoutBar {
+			return m.handleGroupsBarMessage(msg)
+		}
 		return m.handleGroupsMessage(msg)
 	case outputWindow:
 		return m.handleOutputMessage(msg)
@@ -154,47 +598,70 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-// View returns the view for this model. If the application is zoomed on the
-// output window then just the output window and footer are rendered.
-// Otherwise, all of the windows are rendered, with the unfocused windows shown
-// with a faint style.
+// View returns the view for this model. In the minimal layout just the output
+// window, without a border, and the footer are rendered. If the application is
+// zoomed on the output window then just the output window and footer are
+// rendered. Otherwise, all of the windows are rendered, with the unfocused
+// windows shown with a faint style.
 func (m *Model) View() string {
+	if m.minimal {
+		return lipgloss.JoinVertical(lipgloss.Top,
+			m.outputView(),
+			m.footerView(),
+		)
+	}
 	if m.zoomed {
-		border := lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, false, true).BorderForeground(lipgloss.Color("#6CB0D2"))
+		border := lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, false, true).BorderForeground(m.theme.accent)
 		return lipgloss.JoinVertical(lipgloss.Top,
-			border.Render(m.outputModel.View()),
+			m.outputStyle(border).Render(m.outputView()),
 			m.footerView(),
 		)
 	}
-	border := lipgloss.NewStyle().Border(lipgloss.NormalBorder(), true).BorderForeground(lipgloss.Color("#6CB0D2"))
-	faint := border.Faint(true).BorderForeground(lipgloss.Color("#505050"))
+	border := lipgloss.NewStyle().Border(lipgloss.NormalBorder(), true).BorderForeground(m.theme.accent)
+	faint := border.Faint(true).BorderForeground(m.theme.muted)
 	var selectorView, formatView, groupsView, outputView string
 	switch m.selectedWindow {
 	case selectorWindow:
-		selectorView = border.Width(m.selectorModel.Width).Render(m.selectorModel.View())
+		selectorView = m.selectorStyle(border).Width(m.selectorModel.Width).Render(m.selectorModel.View())
 		formatView = faint.Width(m.formatModel.Width).Render(m.formatModel.View())
-		groupsView = faint.Width(m.groupsModel.Width()).Render(m.groupsModel.View())
-		outputView = faint.Width(m.outputModel.Width).Render(m.outputModel.View())
+		groupsView = m.groupsView(faint)
+		outputView = m.outputStyle(faint).Width(m.outputModel.Width + scrollbarWidth).Render(m.outputView())
 	case formatWindow:
-		selectorView = faint.Width(m.selectorModel.Width).Render(m.selectorModel.View())
+		selectorView = m.selectorStyle(faint).Width(m.selectorModel.Width).Render(m.selectorModel.View())
 		formatView = border.Width(m.formatModel.Width).Render(m.formatModel.View())
-		groupsView = faint.Width(m.groupsModel.Width()).Render(m.groupsModel.View())
-		outputView = faint.Width(m.outputModel.Width).Render(m.outputModel.View())
+		groupsView = m.groupsView(faint)
+		outputView = m.outputStyle(faint).Width(m.outputModel.Width + scrollbarWidth).Render(m.outputView())
 	case groupsWindow:
-		selectorView = faint.Width(m.selectorModel.Width).Render(m.selectorModel.View())
+		selectorView = m.selectorStyle(faint).Width(m.selectorModel.Width).Render(m.selectorModel.View())
 		formatView = faint.Width(m.formatModel.Width).Render(m.formatModel.View())
-		groupsView = border.Width(m.groupsModel.Width()).Render(m.groupsModel.View())
-		outputView = faint.Width(m.outputModel.Width).Render(m.outputModel.View())
+		groupsView = m.groupsView(border)
+		outputView = m.outputStyle(faint).Width(m.outputModel.Width + scrollbarWidth).Render(m.outputView())
 	case outputWindow:
-		selectorView = faint.Width(m.selectorModel.Width).Render(m.selectorModel.View())
+		selectorView = m.selectorStyle(faint).Width(m.selectorModel.Width).Render(m.selectorModel.View())
 		formatView = faint.Width(m.formatModel.Width).Render(m.formatModel.View())
-		groupsView = faint.Width(m.groupsModel.Width()).Render(m.groupsModel.View())
-		outputView = border.Width(m.outputModel.Width).Render(m.outputModel.View())
+		groupsView = m.groupsView(faint)
+		outputView = m.outputStyle(border).Width(m.outputModel.Width + scrollbarWidth).Render(m.outputView())
+	}
+	selectorView = markPending(selectorView, m.selectorPending())
+	formatView = markPending(formatView, m.formatPending())
+	groupsView = markPending(groupsView, m.groupPending())
+	if m.scratch {
+		selectorView = border.Width(m.selectorModel.Width).Render(m.scratchModel.View())
+	}
+	if m.groupsLayout == groupsLayoutBar {
+		return lipgloss.JoinVertical(lipgloss.Top,
+			m.titleView(),
+			selectorView,
+			formatView,
+			groupsView,
+			outputView,
+			m.footerView(),
+		)
 	}
 	return strings.Join(
 		[]string{
 			lipgloss.JoinVertical(lipgloss.Top,
-				lipgloss.NewStyle().Width(m.width).Align(lipgloss.Center).Render(m.path),
+				m.titleView(),
 				selectorView,
 				formatView,
 				lipgloss.JoinHorizontal(lipgloss.Top,
@@ -206,6 +673,25 @@ func (m *Model) View() string {
 		}, "\n")
 }
 
+// titleView returns the path of the watched file, followed by the severity
+// counts if a severity field is configured, centered across the window.
+func (m *Model) titleView() string {
+	title := m.path
+	if counts := m.severity.String(); counts != "" {
+		title += "  " + counts
+	}
+	return lipgloss.NewStyle().Width(m.width).Align(lipgloss.Center).Render(title)
+}
+
+// selectorStyle returns the given style with a red border if the selector is
+// not a plausible jq expression.
+func (m *Model) selectorStyle(style lipgloss.Style) lipgloss.Style {
+	if m.selectorInvalid {
+		return style.BorderForeground(m.theme.alert)
+	}
+	return style
+}
+
 // handleProcessorJQCommand handles the processor.JQCommand. This message
 // conveys the jq command that would result in the output being displayed.
 func (m *Model) handleProcessorJQCommand(msg processor.JQCommand) (tea.Model, tea.Cmd) {
@@ -217,32 +703,115 @@ func (m *Model) handleProcessorJQCommand(msg processor.JQCommand) (tea.Model, te
 // message means that the processor has started new read through the watched
 // file. We clear our the content related state from the old processing.
 func (m *Model) handleProcessorContentStart(msg processor.ContentStart) (tea.Model, tea.Cmd) {
+	m.contentErr = nil
+	m.applied.format = m.requested.format
+	m.applied.group = m.requested.group
+	m.loading = false
+	m.waitingFor = ""
+	m.lag = 0
+	m.firstLine = msg.FirstLine
+	m.loadingEarlier = false
+	m.selection = selection{}
+	m.markRow = -1
+	m.formatCache = nil
 	m.rawOutputContent = msg.InitialContent
+	m.rawOutputRecords = msg.InitialRecords
+	m.timeRange = timeRange{}
+	m.lastTimeRecord = processor.Record{}
+	m.severity.reset()
+	for _, record := range m.rawOutputRecords {
+		m.updateTimeRange(record)
+		m.severity.add(record)
+	}
+	m.updateOutputModelContent()
+	if m.showHistogram {
+		// The histogram follows the selected group and format.
+		return m, tea.Batch(m.finishRecordsExport(), m.finishRecordJump(), m.resetIdleTimer(), m.openHistogram())
+	}
+	if m.showTimeline {
+		// The timeline follows the selected group.
+		return m, tea.Batch(m.finishRecordsExport(), m.finishRecordJump(), m.resetIdleTimer(), m.openTimeline())
+	}
+	return m, tea.Batch(m.finishRecordsExport(), m.finishRecordJump(), m.resetIdleTimer(), m.refreshContext(), m.loadEarlierAtTop())
+}
+
+// handleProcessorWaitingForFile handles the processor.WaitingForFile message.
+// This message means that the watched file does not exist yet. The output is
+// cleared until the file is created and its content arrives.
+func (m *Model) handleProcessorWaitingForFile(msg processor.WaitingForFile) (tea.Model, tea.Cmd) {
+	m.loading = false
+	m.waitingFor = msg.Path
+	m.formatCache = nil
+	m.rawOutputContent = nil
+	m.rawOutputRecords = nil
 	m.updateOutputModelContent()
 	return m, nil
 }
 
 // handleProcessorContentError handles the processor.ContentError message. This
 // message means that the processor encountered an error when trying to read
-// content from the watched file.
+// content from the watched file. The error is kept for Err until content is
+// read again.
 func (m *Model) handleProcessorContentError(msg processor.ContentError) (tea.Model, tea.Cmd) {
+	m.contentErr = msg
+	m.loading = false
 	m.jq = msg.Jq
-	cmd := m.groupsModel.SetItems(getGroupItems(m.groups))
+	cmd := m.groupsModel.SetItems(getGroupItems(m.groups, m.pinnedGroups))
 	m.outputModel.SetContent(msg.Err.Error() + "\n" + msg.Message)
 	return m, cmd
 }
 
 // handleProcessorContentLine handles the processor.ContentLine message. This
 // message conveys a new line from the processor that should be displayed in the
-// output window. If we are currently at the bottom then stay there.
+// output window. If we are currently at the bottom then stay there. If dedup is
+// enabled and the line repeats the previous line then the previous line's
+// repeat count is updated instead of adding a new line. New records that match
+// the alert predicate raise an alert, even when the line is hidden or the output
+// window shows something else.
 func (m *Model) handleProcessorContentLine(msg processor.ContentLine) (tea.Model, tea.Cmd) {
+	newRecord := len(m.rawOutputRecords) == 0 || m.rawOutputRecords[len(m.rawOutputRecords)-1] != msg.Record
 	m.rawOutputContent = append(m.rawOutputContent, msg.Line)
-	m.outputContent = append(m.outputContent, formatContentLine(m.wrap, m.lineNumbers, len(m.outputContent)+1, m.outputModel.Width, msg.Line)...)
-	m.outputModel.SetContent(strings.Join(m.outputContent, "\n"))
-	if m.atBottom {
-		m.outputModel.GotoBottom()
+	m.rawOutputRecords = append(m.rawOutputRecords, msg.Record)
+	m.lag = msg.Produced - msg.Seq
+	m.updateTimeRange(msg.Record)
+	m.severity.add(msg.Record)
+	cmd := m.resetIdleTimer()
+	if msg.Record.Alert && newRecord {
+		cmd = tea.Batch(cmd, m.alert())
+	}
+	if !m.lineVisible(len(m.rawOutputContent) - 1) {
+		return m, cmd
+	}
+	idx, count := len(m.rawOutputContent)-1, 1
+	if m.dedup {
+		idx, count = m.repeatStart()
+	}
+	if count > 1 && len(m.outputLines) > 0 {
+		// Replace the previous line with the line and its new repeat count.
+		m.outputLines = m.outputLines[:len(m.outputLines)-1]
+	}
+	m.appendOutputLine(idx, count)
+	if m.showContext && newRecord {
+		return m, tea.Batch(cmd, m.refreshContext())
+	}
+	if m.outputReplaced() {
+		return m, cmd
+	}
+	m.showOutputRows()
+	return m, cmd
+}
+
+// updateTimeRange extends the time range with the timestamp of the given
+// record. Consecutive lines from the same record are only considered once.
+// Records without a parsable timestamp are ignored.
+func (m *Model) updateTimeRange(record processor.Record) {
+	if m.timeField == "" || record == m.lastTimeRecord {
+		return
+	}
+	m.lastTimeRecord = record
+	if t, ok := recordTimestamp(record.JSON, m.timeField); ok {
+		m.timeRange.add(t)
 	}
-	return m, nil
 }
 
 // handleProcessorGroupsStart handles the processor.GroupsStart message. This
@@ -250,25 +819,81 @@ func (m *Model) handleProcessorContentLine(msg processor.ContentLine) (tea.Model
 // file for groups. We clear out our group related state from the old
 // processing.
 func (m *Model) handleProcessorGroupsStart(msg processor.GroupsStart) (tea.Model, tea.Cmd) {
+	m.groupsErr = nil
+	m.readingGroups = false
+	m.applied.selector = m.requested.selector
 	m.groups = map[string]struct{}{}
 	m.groups["*"] = struct{}{}
+	m.groupsTruncated = false
+	m.arrayGroups = msg.Array
+	m.resetGroupCounts()
 	for _, group := range msg.InitialGroups {
-		m.groups[group] = struct{}{}
+		m.countGroup(group)
+		m.addGroup(group)
 	}
-	cmd := m.groupsModel.SetItems(getGroupItems(m.groups))
-	m.groupsModel.ResetSelected()
+	selectedItem := m.groupsModel.SelectedItem()
+	cmd := m.groupsModel.SetItems(getGroupItems(m.groups, m.pinnedGroups))
 	m.updateGroupWidth()
-	return m, tea.Batch(cmd, m.reloadContent)
+	cmds := []tea.Cmd{cmd}
+	if !m.refreshingGroups || !m.reselectGroup(selectedItem) {
+		m.groupsModel.ResetSelected()
+		m.excludedGroups = nil
+		if m.pendingGroup != "" {
+			m.reselectGroup(item(m.pendingGroup))
+		}
+		cmds = append(cmds, m.reloadContent)
+	}
+	m.refreshingGroups = false
+	m.pendingGroup = ""
+	m.updateGroupsTitle()
+	if m.groupsTruncated {
+		cmds = append(cmds, m.truncatedGroupsStatus())
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// selectSeverity groups by the severity field and selects the group of the
+// severity level with the given index, once the groups have been read. Levels
+// that have not been seen in the loaded records are ignored.
+func (m *Model) selectSeverity(index int) tea.Cmd {
+	if m.rawSelector || m.severity.field == "" || index >= len(m.severity.levels) || m.severity.values[index] == "" {
+		return nil
+	}
+	m.pendingGroup = m.severity.values[index]
+	m.selectorModel.SetValue(m.selectorSyntax(m.severity.field))
+	m.selectorInvalid = false
+	return m.reloadGroups
+}
+
+// reselectGroup selects the given group item in the groups window. It returns
+// false if the group is no longer present.
+func (m *Model) reselectGroup(selectedItem list.Item) bool {
+	if selectedItem == nil {
+		return false
+	}
+	for i, item := range m.groupsModel.Items() {
+		if item.FilterValue() == selectedItem.FilterValue() {
+			m.groupsModel.Select(i)
+			return true
+		}
+	}
+	return false
 }
 
 // handleProcessorGroupError handles the processor.GroupError message. This
 // message means that the processor encountered an error when trying to read
-// groups from the watched file.
+// groups from the watched file. The error is kept for Err until groups are
+// read again.
 func (m *Model) handleProcessorGroupError(msg processor.GroupsError) (tea.Model, tea.Cmd) {
+	m.groupsErr = msg
+	m.refreshingGroups = false
+	m.readingGroups = false
 	m.jq = msg.Jq
 	m.groups = map[string]struct{}{}
 	m.groups["*"] = struct{}{}
-	cmd := m.groupsModel.SetItems(getGroupItems(m.groups))
+	m.groupsTruncated = false
+	m.updateGroupsTitle()
+	cmd := m.groupsModel.SetItems(getGroupItems(m.groups, m.pinnedGroups))
 	m.outputModel.SetContent(msg.Err.Error() + "\n" + msg.Message)
 	return m, cmd
 }
@@ -277,11 +902,55 @@ func (m *Model) handleProcessorGroupError(msg processor.GroupsError) (tea.Model,
 // message conveys a new group the processor that should be displayed in the
 // groups window.
 func (m *Model) handleProcessorGroupLine(msg processor.GroupsLine) (tea.Model, tea.Cmd) {
-	m.groups[msg.Line] = struct{}{}
-	groupItems := getGroupItems(m.groups)
+	m.arrayGroups = m.arrayGroups || msg.Array
+	m.countGroup(msg.Line)
+	if _, ok := m.groups[msg.Line]; ok {
+		return m, m.followGroup("")
+	}
+	wasTruncated := m.groupsTruncated
+	if !m.addGroup(msg.Line) {
+		if wasTruncated {
+			return m, nil
+		}
+		m.updateGroupWidth()
+		return m, m.truncatedGroupsStatus()
+	}
+	groupItems := getGroupItems(m.groups, m.pinnedGroups)
 	cmd := m.groupsModel.SetItems(groupItems)
 	m.updateGroupWidth()
-	return m, cmd
+	return m, tea.Batch(cmd, m.followGroup(msg.Line))
+}
+
+// addGroup adds the given group to the set of groups unless doing so would
+// exceed the maximum number of groups. If the group is not added then the
+// groups are marked as truncated and false is returned.
+func (m *Model) addGroup(group string) bool {
+	if _, ok := m.groups[group]; ok {
+		return true
+	}
+	// The "*" group does not count against the maximum.
+	if m.maxGroups > 0 && len(m.groups)-1 >= m.maxGroups {
+		m.groupsTruncated = true
+		return false
+	}
+	m.groups[group] = struct{}{}
+	return true
+}
+
+// truncatedGroupsStatus reports in the footer that the groups list has been
+// truncated.
+func (m *Model) truncatedGroupsStatus() tea.Cmd {
+	return m.setStatus(fmt.Sprintf("more than %d groups, try a coarser selector", m.maxGroups))
+}
+
+// handleProcessorSelectorSuggestion handles the processor.SelectorSuggestion
+// message. This message conveys an existing path similar to a selector that
+// matched nothing. It is shown in the footer if the selector is still current.
+func (m *Model) handleProcessorSelectorSuggestion(msg processor.SelectorSuggestion) (tea.Model, tea.Cmd) {
+	if msg.Selector != m.selectorModel.Value() {
+		return m, nil
+	}
+	return m, m.setStatus(fmt.Sprintf("no matches for %s, did you mean %s?", msg.Selector, msg.Suggestion))
 }
 
 // handleCommandChannel handles the processor.CommandChannel message. This
@@ -289,25 +958,51 @@ func (m *Model) handleProcessorGroupLine(msg processor.GroupsLine) (tea.Model, t
 // commands from the application.
 func (m *Model) handleCommandChannel(msg processor.CommandChannel) (tea.Model, tea.Cmd) {
 	m.processorCmdChan = msg.CmdChan
-	return m, m.reloadContent
+	// The content is reloaded when the processor reports that the groups of
+	// the initial selector have been read.
+	load := m.reloadContent
+	if m.selectorModel.Value() != "" && !m.selectorInvalid {
+		load = m.reloadGroups
+	}
+	if m.showTail {
+		return m, tea.Batch(load, m.reloadTail)
+	}
+	return m, load
 }
 
 // handleWindowSize handles window size messages. It resizes all elements based
-// on the new size and whether the output window is zoomed or not.
+// on the new size and whether the output window is zoomed, or shown alone in the
+// minimal layout, or not.
 func (m *Model) handleWindowSize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 	m.width = msg.Width
 	m.height = msg.Height
 	m.selectorModel.Width = m.width - 2
 	m.formatModel.Width = m.width - 2
+	m.exportModel.Width = m.width - lipgloss.Width(m.exportModel.Prompt) - 2
+	m.recordJumpModel.Width = m.width - lipgloss.Width(m.recordJumpModel.Prompt) - 2
+	m.scratchModel.Width = m.width - 2
 	m.groupsModel.SetHeight(m.height - 10)
-	if m.zoomed {
+	if m.minimal {
+		m.outputModel.Height = m.height - 1
+		m.outputModel.Width = m.width - scrollbarWidth
+	} else if m.zoomed {
 		m.outputModel.Height = m.height - 2
-		m.outputModel.Width = m.width
+		m.outputModel.Width = m.width - scrollbarWidth
+	} else if m.groupsLayout == groupsLayoutBar {
+		m.outputModel.Width = m.width - 2 - scrollbarWidth
+		m.outputModel.Height = m.height - 10 - groupsBarHeight
 	} else {
-		m.outputModel.Width = m.width - m.groupsModel.Width() - 4
+		m.outputModel.Width = m.width - m.groupsModel.Width() - 4 - scrollbarWidth
 		m.outputModel.Height = m.height - 10
 	}
-	m.updateOutputModelContent()
+	m.splitTail()
+	if m.wrap || m.outputReplaced() || len(m.rawOutputContent) == 0 {
+		m.updateOutputModelContent()
+	} else {
+		// Without wrapping, the rows taken by each line do not depend on
+		// the width, so only the visible lines are formatted again.
+		m.showOutputRows()
+	}
 	return m, nil
 }
 
@@ -318,15 +1013,58 @@ func (m *Model) handleWindowSize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 // * tab and shift-tab cycle focus
 // * escape backs out of a form or exits the application
 // * f, when the output window has focus, toggles fullscreen
+// * m, when the output window has focus, toggles the minimal layout
 // * w, when the output window has focus, toggles wrapped
-// * l, when the output window has focus, toggles line numbers
+// * l, when the output window has focus, cycles the gutter between nothing,
+// line numbers, source line numbers, byte offsets, record IDs, and time deltas
+// * U, when the output window has focus, toggles collapsing repeated lines
+// * p, when the output window has focus, pipes the output into a pager
+// * c, when the output window has focus, toggles showing only changed fields
+// * r, when the output window has focus, reloads the groups and content
+// * t, when the output window has focus, toggles skipping records with errors
+// * n, when the output window has focus, toggles hiding null values of the
+// format
+// * J, when the output window has focus, toggles showing the compact JSON of
+// records that the format does not fit
 // * g, when the output window has focus, goes to the top
 // * G, when the output window has focus, goes to the bottom
+// * [ and ], when the output window has focus, go to the previous and next
+// record matching the mark predicate
+// * { and }, when the output window has focus, lower and raise the threshold of
+// the threshold field
+// * ctrl+o, when the selector window has focus, opens the path browser
+// * ctrl+g, when the selector or format window has focus, opens the example
+// picker
+// * T, when the output window has focus, toggles the raw tail pane
+// * H, when the output window has focus, toggles the histogram of the format
+// * a, when the output window has focus, toggles the timeline of the records
+// * C, when the output window has focus, toggles the context view
+// * + and -, when the context view is open, show more or less context
+// * + and -, when the timeline is open, make its buckets finer or coarser
+// * o, when the output window has focus, opens the top record in the editor
+// * v, when the output window has focus, starts or cancels selecting lines
+// * shift+up and shift+down, while selecting lines, extend the selection
+// * y, when lines are selected, copies them to the clipboard
+// * y, when the output window has focus, copies a jq command for the top record
+// * y, when the groups window has focus, copies the groups to the clipboard
+// * s, when the groups window has focus, prompts for a file to save the groups to
+// * < and >, when the groups window has focus, shrink and grow it
+// * =, when the groups window has focus, resets it to fit the groups
+// * %, when the groups window has focus, toggles the share of each group
+// * #, when the output window has focus, prompts for a record ID to jump to
+// * ?, when the output window has focus, toggles an explanation of the stages
+// of the jq command
+// * D, when the groups window has focus, toggles between the compact list and
+// a spaced list with the number of records of each group
+// * F, when the groups window has focus, cycles between following the newest
+// group, following the busiest group, and not following a group
+// * !, when the groups window has focus, toggles excluding the highlighted
+// group from the output
 func (m *Model) handleGlobalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
 	var cmd tea.Cmd
 	switch msg.String() {
 	case "tab":
-		if m.zoomed {
+		if m.zoomed || m.minimal {
 			return m, cmd, false
 		}
 		switch m.selectedWindow {
@@ -345,7 +1083,7 @@ func (m *Model) handleGlobalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
 		}
 		return m, cmd, true
 	case "shift+tab":
-		if m.zoomed {
+		if m.zoomed || m.minimal {
 			return m, cmd, false
 		}
 		switch m.selectedWindow {
@@ -373,6 +1111,26 @@ func (m *Model) handleGlobalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
 			m.groupsModel, cmd = m.groupsModel.Update(msg)
 			return m, cmd, true
 		}
+		if m.showHistogram {
+			m.closeHistogram()
+			return m, cmd, true
+		}
+		if m.showTimeline {
+			m.closeTimeline()
+			return m, cmd, true
+		}
+		if m.showContext {
+			m.closeContext()
+			return m, cmd, true
+		}
+		if m.showExplain {
+			m.closeExplain()
+			return m, cmd, true
+		}
+		if m.selection.active {
+			m.clearSelection()
+			return m, cmd, true
+		}
 		m.stopProcessor()
 		return m, cmd, true
 	case "f":
@@ -382,6 +1140,13 @@ func (m *Model) handleGlobalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
 			return newModel, cmd, true
 		}
 		return m, cmd, false
+	case "m":
+		if m.selectedWindow == outputWindow {
+			m.minimal = !m.minimal
+			newModel, cmd := m.handleWindowSize(tea.WindowSizeMsg{Height: m.height, Width: m.width})
+			return newModel, cmd, true
+		}
+		return m, cmd, false
 	case "w":
 		if m.selectedWindow == outputWindow {
 			m.wrap = !m.wrap
@@ -391,59 +1156,371 @@ func (m *Model) handleGlobalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
 		return m, cmd, false
 	case "l":
 		if m.selectedWindow == outputWindow {
-			m.lineNumbers = !m.lineNumbers
+			m.gutter = (m.gutter + 1) % (gutterDeltas + 1)
+			if m.gutter == gutterDeltas && m.timeField == "" {
+				// Deltas need a time field.
+				m.gutter = gutterNone
+			}
+			m.updateOutputModelContent()
+			return m, m.reloadContentForRecords(), true
+		}
+		return m, cmd, false
+	case "U":
+		if m.selectedWindow == outputWindow {
+			m.dedup = !m.dedup
+			m.updateOutputModelContent()
+			return m, cmd, true
+		}
+		return m, cmd, false
+	case "c":
+		if m.selectedWindow == outputWindow {
+			m.diffView = !m.diffView
 			m.updateOutputModelContent()
+			return m, m.reloadContentForRecords(), true
+		}
+		return m, cmd, false
+	case "p":
+		if m.selectedWindow == outputWindow {
+			return m, m.openPager(), true
+		}
+		return m, cmd, false
+	case ":":
+		if m.selectedWindow == outputWindow {
+			return m, m.openScratch(), true
+		}
+		return m, cmd, false
+	case "ctrl+o":
+		if m.selectedWindow == selectorWindow && !m.rawSelector {
+			return m, m.openBrowse(), true
+		}
+		return m, cmd, false
+	case "ctrl+g":
+		if m.selectedWindow == selectorWindow || m.selectedWindow == formatWindow {
+			m.openExamples(m.selectedWindow)
 			return m, cmd, true
 		}
 		return m, cmd, false
+	case "ctrl+x":
+		if m.selectedWindow == selectorWindow || m.selectedWindow == formatWindow {
+			return m, m.swapSelectorAndFormat(), true
+		}
+		return m, cmd, false
+	case "T":
+		if m.selectedWindow == outputWindow {
+			return m, m.toggleTail(), true
+		}
+		return m, cmd, false
+	case "H":
+		if m.selectedWindow == outputWindow {
+			if m.showHistogram {
+				m.closeHistogram()
+				return m, cmd, true
+			}
+			return m, m.openHistogram(), true
+		}
+		return m, cmd, false
+	case "a":
+		if m.selectedWindow == outputWindow {
+			if m.showTimeline {
+				m.closeTimeline()
+				return m, cmd, true
+			}
+			return m, m.openTimeline(), true
+		}
+		return m, cmd, false
+	case "C":
+		if m.selectedWindow == outputWindow {
+			if m.showContext {
+				m.closeContext()
+				return m, cmd, true
+			}
+			return m, m.openContext(), true
+		}
+		return m, cmd, false
+	case "+", "-":
+		if m.selectedWindow == outputWindow && m.showTimeline {
+			return m, m.resizeTimelineBuckets(msg.String() == "+"), true
+		}
+		if m.selectedWindow == outputWindow && m.showContext {
+			delta := 1
+			if msg.String() == "-" {
+				delta = -1
+			}
+			return m, m.resizeContext(delta), true
+		}
+		return m, cmd, false
+	case "v":
+		if m.selectedWindow == outputWindow {
+			if m.selection.active {
+				m.clearSelection()
+				return m, cmd, true
+			}
+			return m, m.startSelection(), true
+		}
+		return m, cmd, false
+	case "shift+up", "shift+down":
+		if m.selectedWindow == outputWindow && m.selection.active {
+			delta := 1
+			if msg.String() == "shift+up" {
+				delta = -1
+			}
+			m.extendSelection(delta)
+			return m, cmd, true
+		}
+		return m, cmd, false
+	case "o":
+		if m.selectedWindow == outputWindow {
+			return m, m.openRecordInEditor(), true
+		}
+		return m, cmd, false
+	case "?":
+		if m.selectedWindow == outputWindow {
+			if m.showExplain {
+				m.closeExplain()
+			} else {
+				m.openExplain()
+			}
+			return m, cmd, true
+		}
+		return m, cmd, false
+	case "e":
+		if m.selectedWindow == outputWindow {
+			return m, m.startRecordsExport(), true
+		}
+		return m, cmd, false
+	case "#":
+		if m.selectedWindow == outputWindow {
+			return m, m.startRecordJump(), true
+		}
+		return m, cmd, false
+	case "r":
+		if m.selectedWindow == outputWindow {
+			// Content is reloaded when the processor reports that the groups
+			// have been reloaded.
+			return m, tea.Batch(m.reloadGroups, m.setStatus("reloaded")), true
+		}
+		if m.selectedWindow == groupsWindow && m.groupsModel.FilterState() != list.Filtering {
+			return m, m.refreshGroups, true
+		}
+		return m, cmd, false
+	case "t":
+		if m.selectedWindow == outputWindow {
+			m.lenient = !m.lenient
+			status := "reporting errors"
+			if m.lenient {
+				status = "skipping errors"
+			}
+			// Content is reloaded when the processor reports that the groups
+			// have been reloaded.
+			return m, tea.Batch(m.reloadGroups, m.setStatus(status)), true
+		}
+		return m, cmd, false
+	case "n":
+		if m.selectedWindow == outputWindow {
+			m.hideNulls = !m.hideNulls
+			status := "showing nulls"
+			if m.hideNulls {
+				status = "hiding nulls"
+			}
+			return m, tea.Batch(m.reloadContent, m.setStatus(status)), true
+		}
+		return m, cmd, false
+	case "J":
+		if m.selectedWindow == outputWindow {
+			m.rawFallback = !m.rawFallback
+			status := "leaving out records the format does not fit"
+			if m.rawFallback {
+				status = "showing the JSON of records the format does not fit"
+			}
+			return m, tea.Batch(m.reloadContent, m.setStatus(status)), true
+		}
+		return m, cmd, false
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		if m.selectedWindow == outputWindow {
+			return m, m.selectSeverity(int(msg.String()[0] - '1')), true
+		}
+		return m, cmd, false
+	case "{", "}":
+		if m.selectedWindow == outputWindow {
+			delta := 1
+			if msg.String() == "{" {
+				delta = -1
+			}
+			return m, m.moveThreshold(delta), true
+		}
+		return m, cmd, false
+	case "[", "]":
+		if m.selectedWindow == outputWindow && !m.outputReplaced() {
+			return m, m.jumpToMark(msg.String() == "]"), true
+		}
+		return m, cmd, false
 	case "G":
 		if m.selectedWindow == outputWindow {
-			m.outputModel.GotoBottom()
-			m.atBottom = true
+			m.outputGotoBottom()
 			return m, cmd, true
 		}
 		return m, cmd, false
 	case "g":
 		if m.selectedWindow == outputWindow {
-			m.atBottom = false
-			m.outputModel.GotoTop()
+			m.outputGotoTop()
+			return m, m.loadEarlierAtTop(), true
+		}
+		return m, cmd, false
+	case "y":
+		if m.selectedWindow == groupsWindow && m.groupsModel.FilterState() != list.Filtering {
+			return m, m.copyGroups(), true
+		}
+		if m.selectedWindow == outputWindow && m.selection.active {
+			return m, m.copySelection(), true
+		}
+		if m.selectedWindow == outputWindow {
+			return m, m.copyRecordCommand(), true
+		}
+		return m, cmd, false
+	case "R":
+		if m.selectedWindow == outputWindow {
+			return m, m.copyReplayCommand(), true
+		}
+		return m, cmd, false
+	case "s":
+		if m.selectedWindow == groupsWindow && m.groupsModel.FilterState() != list.Filtering {
+			return m, m.startGroupsExport(), true
+		}
+		if m.selectedWindow == outputWindow {
+			m.showSummary = !m.showSummary
 			return m, cmd, true
 		}
 		return m, cmd, false
+	case "x":
+		if m.selectedWindow == groupsWindow && m.groupsModel.FilterState() != list.Filtering && !m.rawSelector {
+			m.exists = !m.exists
+			m.selectorModel.Prompt = selectorPrompt(m.rawSelector, m.exists, m.pointer)
+			// Content is reloaded when the processor reports that the groups
+			// have been reloaded.
+			return m, m.reloadGroups, true
+		}
+		return m, cmd, false
+	case "<", ">":
+		if m.selectedWindow == groupsWindow && m.groupsModel.FilterState() != list.Filtering {
+			delta := 2
+			if msg.String() == "<" {
+				delta = -delta
+			}
+			m.resizeGroups(delta)
+			return m, cmd, true
+		}
+		return m, cmd, false
+	case "=":
+		if m.selectedWindow == groupsWindow && m.groupsModel.FilterState() != list.Filtering {
+			m.groupsWidth = 0
+			m.updateGroupWidth()
+			return m, cmd, true
+		}
+		return m, cmd, false
+	case "!":
+		if m.selectedWindow == groupsWindow && m.groupsModel.FilterState() != list.Filtering && !m.rawSelector {
+			return m, m.toggleExcludedGroup(), true
+		}
+		return m, cmd, false
+	case "%":
+		if m.selectedWindow == groupsWindow && m.groupsModel.FilterState() != list.Filtering {
+			m.showShares = !m.showShares
+			m.updateGroupWidth()
+			return m, cmd, true
+		}
+		return m, cmd, false
+	case "D":
+		if m.selectedWindow == groupsWindow && m.groupsModel.FilterState() != list.Filtering {
+			m.toggleGroupSpacing()
+			return m, cmd, true
+		}
+		return m, cmd, false
+	case "F":
+		if m.selectedWindow == groupsWindow && m.groupsModel.FilterState() != list.Filtering {
+			return m, m.cycleGroupFollow(), true
+		}
+		return m, cmd, false
 	}
 	return m, cmd, false
 }
 
 // handleSelectorMessage handles messages sent to the selector window. If the
 // value of the selector changed based on the message, then a command is sent to
-// the processor to re-start watching the file for groups.
+// the processor to re-start watching the file for groups once typing pauses
+// for the reload debounce interval. Selectors that are not plausible jq
+// expressions are flagged and not sent to the processor.
 func (m *Model) handleSelectorMessage(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
+	msg = normalizePaste(msg)
 	origValue := m.selectorModel.Value()
 	m.selectorModel, cmd = m.selectorModel.Update(msg)
 	newValue := m.selectorModel.Value()
 	if origValue == newValue {
 		return m, cmd
 	}
-	// A selector that ends in a '.' is never valid.
-	if len(newValue) > 1 && strings.HasSuffix(newValue, ".") {
+	m.selectorInvalid = !m.plausibleSelector(newValue)
+	if m.selectorInvalid {
 		return m, cmd
 	}
-	return m, tea.Batch(cmd, m.reloadGroups)
+	return m, tea.Batch(cmd, m.debounceReload(selectorWindow))
 }
 
 // handleFormatMessage handles messages sent to the format window. If the value
 // of the format changed based on the message, then a comnmand is sent to the
-// processor to re-start watching the file for content.
+// processor to re-start watching the file for content once typing pauses for
+// the reload debounce interval.
 func (m *Model) handleFormatMessage(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
+	msg = normalizePaste(msg)
 	origValue := m.formatModel.Value()
 	m.formatModel, cmd = m.formatModel.Update(msg)
 	newValue := m.formatModel.Value()
 	if origValue == newValue {
 		return m, cmd
 	}
-	return m, tea.Batch(cmd, m.reloadContent)
+	return m, tea.Batch(cmd, m.debounceReload(formatWindow))
+}
+
+// swapSelectorAndFormat swaps the values of the selector and format windows
+// and reloads the groups, which reloads the content once they are read. In
+// pointer mode the selector is written as a jq path in the format, and a
+// format that is a simple path is written as a JSON pointer in the selector.
+// If the new selector is not plausible then only the content is reloaded.
+func (m *Model) swapSelectorAndFormat() tea.Cmd {
+	selector, format := m.selectorModel.Value(), m.formatModel.Value()
+	if m.pointer && !m.rawSelector {
+		if path, err := processor.PointerToPath(selector); err == nil && selector != "" {
+			selector = path
+		}
+		if simplePathPattern.MatchString(format) {
+			format = processor.PathToPointer(format)
+		}
+	}
+	m.selectorModel.SetValue(format)
+	m.formatModel.SetValue(selector)
+	m.selectorInvalid = !m.plausibleSelector(format)
+	if m.selectorInvalid {
+		return m.reloadContent
+	}
+	return m.reloadGroups
+}
+
+// pastedLineBreak matches a line break in pasted text along with the
+// indentation around it.
+var pastedLineBreak = regexp.MustCompile(`[ \t]*\r?\n[ \t]*`)
+
+// normalizePaste returns the given message with the line breaks of a bracketed
+// paste, and the indentation around them, replaced by single spaces so that a
+// multi-line jq expression is inserted into a text input as a single line.
+// Other messages are returned unchanged.
+func normalizePaste(msg tea.Msg) tea.Msg {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || !keyMsg.Paste {
+		return msg
+	}
+	text := strings.TrimSpace(pastedLineBreak.ReplaceAllString(string(keyMsg.Runes), " "))
+	keyMsg.Runes = []rune(text)
+	return keyMsg
 }
 
 // handleGroupsMessage handles messages sent to the groups list window. If the
@@ -457,63 +1534,381 @@ func (m *Model) handleGroupsMessage(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if origValue == newValue {
 		return m, cmd
 	}
-	return m, tea.Batch(cmd, m.reloadContent)
+	return m, tea.Batch(cmd, m.groupChanged())
 }
 
 // hadleOutputMessage handles messages sent to the output window. If the message
 // put us at the bottom of the window then we remember that we are at the bottom
 // so we can stay there as new lines are added.
 func (m *Model) handleOutputMessage(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-	m.outputModel, cmd = m.outputModel.Update(msg)
-	m.atBottom = (m.outputModel.ScrollPercent() == 1.0)
-	return m, cmd
+	if m.outputReplaced() {
+		var cmd tea.Cmd
+		m.outputModel, cmd = m.outputModel.Update(msg)
+		return m, cmd
+	}
+	m.scrollOutput(msg)
+	m.atBottom = m.outputScrollPercent() == 1.0
+	return m, m.loadEarlierAtTop()
 }
 
 // footerView returns the view of the footer. It contains the current jq command
 // and the current scroll percentage of the output window with enough space
-// between them to put the percentage at the right of the screen.
+// between them to put the percentage at the right of the screen. If there is a
+// status message then it is shown in place of the jq command. If a time field
+// is configured then the range of loaded timestamps is shown before the
+// percentage. If the output is more than lagThreshold lines behind the stream
+// then that is shown before the percentage too, as is the first line read when
+// earlier lines of the file are not loaded. A spinner next to the percentage
+// shows that content or groups are being read.
 func (m *Model) footerView() string {
-	scrollPercent := fmt.Sprintf("%3.f%%", m.outputModel.ScrollPercent()*100)
-	spaceCount := m.selectorModel.Width - len(scrollPercent) - 1
+	if m.exportModel.Focused() {
+		return " " + m.exportModel.View()
+	}
+	if m.recordJumpModel.Focused() {
+		return " " + m.recordJumpModel.View()
+	}
+	scrollPercent := fmt.Sprintf("%3.f%%", m.outputScrollPercent()*100)
+	if indicator := m.busyIndicator(); indicator != "" {
+		scrollPercent = indicator + " " + scrollPercent
+	}
+	if timeRange := m.timeRange.String(); timeRange != "" {
+		scrollPercent = timeRange + "  " + scrollPercent
+	}
+	if m.lag > lagThreshold {
+		scrollPercent = fmt.Sprintf("behind by %d lines  %s", m.lag, scrollPercent)
+	}
+	if earlier := m.earlierLinesStatus(); earlier != "" {
+		scrollPercent = earlier + "  " + scrollPercent
+	}
+	if threshold := m.thresholdStatus(); threshold != "" {
+		scrollPercent = threshold + "  " + scrollPercent
+	}
+	if follow := m.groupFollowStatus(); follow != "" {
+		scrollPercent = follow + "  " + scrollPercent
+	}
+	spaceCount := m.selectorModel.Width - lipgloss.Width(scrollPercent) - 1
 	if spaceCount < 4 {
 		return ""
 	}
-	if spaceCount < len(m.jq) {
+	text := m.jq
+	if m.showSummary {
+		text = m.querySummary()
+	}
+	if m.scratch {
+		text = m.scratchJq
+	}
+	if m.showHistogram {
+		text = m.histogram.Jq
+	}
+	if m.showTimeline {
+		text = m.timelineHelp()
+	}
+	if m.showContext {
+		text = m.contextHelp()
+	}
+	if m.selection.active {
+		text = selectionHelp
+	}
+	if m.browsing {
+		text = browseHelp
+	}
+	if m.showExamples {
+		text = examplesHelp
+	}
+	if m.showExplain {
+		text = explainHelp
+	}
+	if m.status != "" {
+		text = m.status
+	}
+	if m.refreshingGroups {
+		text = m.spinner.View() + " refreshing groups"
+	}
+	if spaceCount < len(text) {
 		fmtString := fmt.Sprintf(" %%-%d.%ds... %%s", spaceCount-3, spaceCount-3)
-		return fmt.Sprintf(fmtString, m.jq, scrollPercent)
+		return fmt.Sprintf(fmtString, text, scrollPercent)
 	}
 	fmtString := fmt.Sprintf(" %%-%d.%ds %%s", spaceCount, spaceCount)
-	return fmt.Sprintf(fmtString, m.jq, scrollPercent)
+	return fmt.Sprintf(fmtString, text, scrollPercent)
 }
 
-// updateGroupWidth sizes the groups window to fit the current list of groups.
-// If there is a change then it also resizes the output window and re-formats
-// the content in that window.
+// resetIdleTimer returns a tea.Cmd that exits the application after the idle
+// timeout unless the timer is reset again before then. It returns nil if there
+// is no idle timeout.
+func (m *Model) resetIdleTimer() tea.Cmd {
+	if m.idleTimeout <= 0 {
+		return nil
+	}
+	m.idleID++
+	id := m.idleID
+	return tea.Tick(m.idleTimeout, func(time.Time) tea.Msg {
+		return idleTimeout{id: id}
+	})
+}
+
+// setStatus shows the given message in the footer. It returns a tea.Cmd that
+// clears the message after statusTimeout.
+func (m *Model) setStatus(status string) tea.Cmd {
+	m.statusID++
+	m.status = status
+	id := m.statusID
+	return tea.Tick(statusTimeout, func(time.Time) tea.Msg {
+		return clearStatus{id: id}
+	})
+}
+
+// updateGroupWidth sizes the groups window to fit the current list of groups,
+// or to the width chosen by the user if they have resized it. If there is a
+// change then it also resizes the output window and re-formats the content in
+// that window.
 func (m *Model) updateGroupWidth() {
 	currentWidth := m.groupsModel.Width()
-	newWidth := getGroupWidth(m.groups)
+	newWidth := m.groupsFitWidth()
+	if m.groupsWidth > 0 {
+		newWidth = m.groupsWidth
+	}
+	defer m.updateGroupsTitle()
 	if currentWidth != newWidth {
 		m.groupsModel.SetWidth(newWidth)
-		m.outputModel.Width = m.width - m.groupsModel.Width() - 4
+		if m.groupsLayout == groupsLayoutBar {
+			return
+		}
+		m.outputModel.Width = m.width - m.groupsModel.Width() - 4 - scrollbarWidth
 		m.updateOutputModelContent()
 	}
 }
 
+// resizeGroups changes the width of the groups window by the given amount. The
+// chosen width overrides the width derived from the groups until it is reset.
+func (m *Model) resizeGroups(delta int) {
+	width := m.groupsModel.Width() + delta
+	m.groupsWidth = max(minGroupsWidth, min(width, m.width-minOutputWidth))
+	m.updateGroupWidth()
+}
+
+// groupsCountTitle returns the number of groups, excluding "*", for the title
+// of the groups window. It notes that the list was truncated when there are
+// more groups than the configured maximum.
+func (m *Model) groupsCountTitle() string {
+	if m.groupsTruncated {
+		return fmt.Sprintf("%d+ groups (truncated)", m.maxGroups)
+	}
+	return fmt.Sprintf("groups (%d)", len(m.groups)-1)
+}
+
+// groupsFitWidth returns the width of the groups window that fits the groups,
+// with their shares when those are shown and the marker of excluded groups,
+// and the number of groups in the title.
+func (m *Model) groupsFitWidth() int {
+	width := getGroupWidth(m.groups) + m.excludedWidth()
+	if m.showShares {
+		width += groupShareWidth
+	}
+	if m.spacedGroups {
+		// "*" has the most records so its description is the widest.
+		width = max(width, len(m.groupDescription("*")))
+	}
+	return max(width, m.groupsTitleFrameSize()+lipgloss.Width(m.groupsCountTitle()))
+}
+
+// groupsTitleFrameSize returns the width taken by the styles of the title of
+// the groups window and by the gap the list leaves for its status message.
+func (m *Model) groupsTitleFrameSize() int {
+	return m.groupsModel.Styles.TitleBar.GetHorizontalFrameSize() + m.groupsModel.Styles.Title.GetHorizontalFrameSize() + 2
+}
+
+// updateGroupsTitle sets the title of the groups window to the number of groups
+// or, when a group other than "*" is selected, to the number of groups and the
+// selected group, like "4: error". The selected group is truncated so that the
+// title does not widen the window. The title takes the place of the filter
+// prompt so showing it does not move the list.
+func (m *Model) updateGroupsTitle() {
+	title := m.groupsCountTitle()
+	if group := m.selectedGroup(); group != "*" {
+		count := strconv.Itoa(len(m.groups) - 1)
+		if m.groupsTruncated {
+			count = strconv.Itoa(m.maxGroups) + "+"
+		}
+		title = ansi.Truncate(count+": "+group, m.groupsModel.Width()-m.groupsTitleFrameSize(), "…")
+	}
+	m.groupsModel.Title = title
+}
+
 // updateOutputModelContent re-formats all of the cached content lines for the
 // current state of the applicaton (window sizes, line numbers, wrapping, etc).
 // This is only necessary because the viewport does not correctly handle scroll
 // position when doing its own wrapping.
 // (https://github.com/charmbracelet/bubbletea/issues/1017)
 func (m *Model) updateOutputModelContent() {
-	// reformat all lines
-	m.outputContent = make([]string, 0, max(len(m.rawOutputContent), len(m.outputContent)))
-	for idx, line := range m.rawOutputContent {
-		m.outputContent = append(m.outputContent, formatContentLine(m.wrap, m.lineNumbers, idx+1, m.outputModel.Width, line)...)
+	if m.scratch {
+		m.outputModel.SetContent(m.scratchContent())
+		return
+	}
+	if m.showHistogram {
+		m.outputModel.SetContent(m.histogramContent())
+		return
+	}
+	if m.showTimeline {
+		m.outputModel.SetContent(m.timelineContent())
+		return
 	}
-	m.outputModel.SetContent(strings.Join(m.outputContent, "\n"))
-	if m.atBottom {
-		m.outputModel.GotoBottom()
+	if m.browsing {
+		m.outputModel.SetContent(m.browseContent())
+		m.scrollBrowse()
+		return
+	}
+	if m.showContext {
+		m.outputModel.SetContent(m.contextContent())
+		return
+	}
+	if m.showExamples {
+		m.outputModel.SetContent(m.examplesContent())
+		return
+	}
+	if m.showExplain {
+		m.outputModel.SetContent(m.explainContent())
+		return
+	}
+	// Index the rows of all lines. Only the visible lines are formatted.
+	m.outputLines = m.outputLines[:0]
+	m.eachOutputLine(func(idx, count int) bool {
+		m.appendOutputLine(idx, count)
+		return true
+	})
+	if len(m.rawOutputContent) == 0 {
+		// Nothing has been read yet, as when the file is empty or does not
+		// exist. The placeholder is replaced by the first line that arrives.
+		placeholder := waitingPlaceholder
+		if m.waitingFor != "" {
+			placeholder = "waiting for " + m.waitingFor + "…"
+		}
+		m.outputModel.SetContent(placeholder)
+		return
+	}
+	m.showOutputRows()
+}
+
+// eachOutputLine calls fn with the index of each visible cached content line
+// and the number of times it is repeated, skipping the repeats when dedup is
+// enabled. It stops when fn returns false.
+func (m *Model) eachOutputLine(fn func(idx, count int) bool) {
+	for idx, count := 0, 1; idx < len(m.rawOutputContent); idx += count {
+		count = 1
+		if !m.lineVisible(idx) {
+			continue
+		}
+		for m.dedup && idx+count < len(m.rawOutputContent) && m.rawOutputContent[idx+count] == m.rawOutputContent[idx] {
+			count++
+		}
+		if !fn(idx, count) {
+			return
+		}
+	}
+}
+
+// repeatStart returns the index of the last line shown by eachOutputLine and
+// the number of times it is repeated, when the last cached content line repeats
+// the line before it. The repeats start at the first visible line of the run
+// of identical lines at the end of the cached content.
+func (m *Model) repeatStart() (int, int) {
+	last := len(m.rawOutputContent) - 1
+	start := last
+	for start > 0 && m.rawOutputContent[start-1] == m.rawOutputContent[last] {
+		start--
+	}
+	for start < last && !m.lineVisible(start) {
+		start++
+	}
+	return start, last + 1 - start
+}
+
+// lineText returns the text shown for the cached content line at the given
+// index, or false if the line is not shown. A count greater than one indicates
+// that the line is repeated that many times and is shown with a repeat suffix.
+// In the diff view, records are shown as their changes from the previous
+// record.
+func (m *Model) lineText(idx, count int) (string, bool) {
+	line := m.rawOutputContent[idx]
+	if m.diffView {
+		diff, skip, ok := m.diffLine(idx)
+		if skip {
+			return "", false
+		}
+		if ok {
+			line = diff
+		}
+	}
+	if count > 1 {
+		line = fmt.Sprintf("%s (x%d)", line, count)
+	}
+	return line, true
+}
+
+// lineRows returns the number of rows of the output window taken by the cached
+// content line at the given index, repeated the given number of times, without
+// formatting it. The gutter only matters to wrapped lines.
+func (m *Model) lineRows(idx, count int) int {
+	line, ok := m.lineText(idx, count)
+	if !ok {
+		return 0
+	}
+	gutter := ""
+	if m.wrap {
+		gutter = m.gutterText(idx)
+	}
+	return contentLineRows(m.formatOptions(), gutter, line)
+}
+
+// formatLine returns the cached content line at the given index, repeated the
+// given number of times, formatted for the current state of the application.
+func (m *Model) formatLine(idx, count int) []string {
+	line, ok := m.lineText(idx, count)
+	if !ok {
+		return nil
+	}
+	opts := m.formatOptions()
+	opts.color = m.lineColor(idx)
+	opts.selected = m.selection.contains(idx)
+	if m.colorJSON && !m.diffView && (m.applied.format == "" || m.applied.format == ".") {
+		opts.json = m.theme.json
+	}
+	return m.formatCached(idx, opts, m.gutterText(idx), line)
+}
+
+// gutterText returns the gutter for the cached content line at the given
+// index. Source line numbers, byte offsets, and record IDs are blank for lines
+// whose record is not known, and time deltas are blank where timeDelta has none.
+func (m *Model) gutterText(idx int) string {
+	switch m.gutter {
+	case gutterLineNumbers:
+		return fmt.Sprintf("%5d: ", idx+1)
+	case gutterSourceLines:
+		if line := m.rawOutputRecords[idx].Line; line > 0 {
+			return fmt.Sprintf("%7d: ", line)
+		}
+		return fmt.Sprintf("%7s: ", "")
+	case gutterOffsets:
+		if offset := m.rawOutputRecords[idx].Offset; offset >= 0 {
+			return fmt.Sprintf("%10d: ", offset)
+		}
+		return fmt.Sprintf("%10s: ", "")
+	case gutterRecordIDs:
+		return fmt.Sprintf("%*s: ", recordIDLength, m.lineRecordID(idx))
+	case gutterDeltas:
+		return fmt.Sprintf("%8s: ", m.timeDelta(idx))
+	}
+	return ""
+}
+
+// formatOptions returns the formatOptions for the current state of the
+// application.
+func (m *Model) formatOptions() formatOptions {
+	return formatOptions{
+		wrapped:    m.wrap,
+		width:      m.outputModel.Width,
+		tabWidth:   m.tabWidth,
+		sanitize:   m.sanitize,
+		wrapIndent: m.wrapIndent,
 	}
 }
 
@@ -528,62 +1923,339 @@ func (m *Model) stopProcessor() {
 
 // reloadGroups is a tea.Cmd that issues a processor.StartGroupsOperation to the
 // currently connected processor. This begins the process of re-reading groups
-// from the file. It returns no message.
+// from the file. The content is reloaded once the groups have been read. It
+// returns a groupsRequested message.
 func (m *Model) reloadGroups() tea.Msg {
+	return m.startGroups(false)
+}
+
+// refreshGroups is a tea.Cmd like reloadGroups except that the selected group
+// and the content are kept if the selected group is still present once the
+// groups have been read. It returns a groupsRequested message.
+func (m *Model) refreshGroups() tea.Msg {
+	return m.startGroups(true)
+}
+
+// startGroups issues a processor.StartGroupsOperation to the currently
+// connected processor. If refresh is set then the content is only reloaded if
+// the selected group disappears. It returns a groupsRequested message.
+func (m *Model) startGroups(refresh bool) tea.Msg {
+	m.refreshingGroups = refresh
+	m.readingGroups = true
+	m.requested.selector = m.selectorModel.Value()
 	m.groups = map[string]struct{}{}
 	m.groups["*"] = struct{}{}
 	m.processorCmdChan <- processor.Command{
-		Operation: processor.StartGroupsOperation,
-		Selector:  m.selectorModel.Value(),
-		Path:      m.path,
+		Operation:    processor.StartGroupsOperation,
+		Selector:     m.selectorModel.Value(),
+		Path:         m.path,
+		RawSelector:  m.rawSelector,
+		Exists:       m.exists,
+		MaxLineBytes: m.maxLineBytes,
+		Relaxed:      m.relaxed,
+		SortKeys:     m.sortKeys,
+		Lenient:      m.lenient,
+		FromLine:     m.fromLine,
+		Redact:       m.redact,
+		Encoding:     m.encoding,
+		NoEnv:        m.noEnv,
+		Pointer:      m.pointer,
+		TimeField:    m.timeField,
+		Since:        m.since,
+		Until:        m.until,
+	}
+	return groupsRequested{}
+}
+
+// needRecords returns true if any enabled feature requires the compact JSON of
+// the record that produced each line of content.
+func (m *Model) needRecords() bool {
+	return m.timeField != "" || m.severity.field != "" || m.alertPredicate != "" || m.markPredicate != "" || m.liveGroups || m.diffView ||
+		m.gutter == gutterSourceLines || m.gutter == gutterOffsets || m.gutter == gutterRecordIDs || m.gutter == gutterDeltas || m.exportPath != "" || m.recordJumpID != "" || m.inspectRecords || m.groupColors || m.showContext
+}
+
+// reloadContentForRecords returns reloadContent if records are needed but were
+// not requested for the currently loaded content. Otherwise it returns nil.
+func (m *Model) reloadContentForRecords() tea.Cmd {
+	if m.needRecords() && !m.recordsLoaded {
+		return m.reloadContent
 	}
 	return nil
 }
 
 // reloadContent is a tea.Cmd that issues a processor.StartContentOperation to
 // the currently connected processor. This begins the process of re-reading
-// content from the file. It returns no message.
+// content from the file. The current output stays until the new content
+// arrives, or is replaced by a loading placeholder after loadingDelay. It
+// returns a contentRequested message.
 func (m *Model) reloadContent() tea.Msg {
+	m.loading = true
+	m.loadingID++
+	id := m.loadingID
+	m.recordsLoaded = m.needRecords()
+	m.requested.format = m.formatModel.Value()
+	m.requested.group = m.selectedGroup()
+	cmd := m.contentCommand()
+	cmd.Operation = processor.StartContentOperation
+	cmd.LastLines = m.lastLines
+	m.processorCmdChan <- cmd
+	return contentRequested{id: id}
+}
+
+// contentCommand returns a processor.Command that reads the content for the
+// current selector, format, and group.
+func (m *Model) contentCommand() processor.Command {
+	selectedItemText := m.selectedGroup()
+	if m.liveFiltering() {
+		// The selected group is shown by filtering the records in the model.
+		selectedItemText = "*"
+	}
+	cmd := processor.Command{
+		Selector:       m.selectorModel.Value(),
+		Format:         m.formatModel.Value(),
+		Group:          selectedItemText,
+		ExcludedGroups: m.excludedGroups,
+		Path:           m.path,
+		RawSelector:    m.rawSelector,
+		Exists:         m.exists,
+		MaxLineBytes:   m.maxLineBytes,
+		Records:        m.recordsLoaded,
+		Offsets:        m.recordsLoaded,
+		Relaxed:        m.relaxed,
+		SortKeys:       m.sortKeys,
+		Indent:         m.indent,
+		Tab:            m.tab,
+		Lenient:        m.lenient,
+		FromLine:       m.fromLine,
+		Redact:         m.redact,
+		Encoding:       m.encoding,
+		NoEnv:          m.noEnv,
+		Alert:          m.alertPredicate,
+		Mark:           m.markPredicate,
+		ArrayGroups:    m.arrayGroups,
+		Pointer:        m.pointer,
+		NulDelimited:   m.nulDelimited,
+		OneLine:        m.oneLine,
+		HideNulls:      m.hideNulls,
+		RawFallback:    m.rawFallback,
+		Throttle:       m.throttle,
+		TimeField:      m.timeField,
+		Since:          m.since,
+		Until:          m.until,
+	}
+	if m.threshold.active() {
+		cmd.ThresholdField = m.threshold.field
+		cmd.Threshold = m.threshold.value()
+	}
+	return cmd
+}
+
+// showLoadingPlaceholder replaces the output with a loading placeholder until
+// the requested content arrives.
+func (m *Model) showLoadingPlaceholder() {
 	m.rawOutputContent = []string{"Loading..."}
-	m.outputContent = []string{"Loading..."}
+	m.rawOutputRecords = []processor.Record{{Offset: -1}}
+	m.outputLines = []outputLine{{rows: 1}}
 	m.outputModel.SetContent("Loading...")
-	selectedItem := m.groupsModel.SelectedItem()
-	selectedItemText := "*"
-	if selectedItem != nil {
-		selectedItemText = selectedItem.FilterValue()
+}
+
+// formatOptions holds the characteristics used by formatContentLine.
+type formatOptions struct {
+	wrapped  bool
+	width    int
+	tabWidth int
+	sanitize bool
+	// wrapIndent prefixes the continuation rows of wrapped lines.
+	wrapIndent string
+	// color is the foreground color of the line, or "" for the default.
+	color lipgloss.Color
+	// selected indicates that the line is highlighted as selected.
+	selected bool
+	// json colors the tokens of the line as JSON unless it is colored or
+	// selected.
+	json jsonColors
+}
+
+// formatContentLine returns the given line, prefixed with the given gutter,
+// formatted with the given characteristics. Tabs are expanded before the line
+// is truncated or wrapped so that the width of the line is predictable. If
+// sanitize is set then control characters are escaped first. Lines holding
+// newlines, as NUL delimited content may, have each row formatted separately
+// with a blank gutter for the rows after the first. If a color is set then the
+// line, but not the gutter, is shown in that color, and selected lines are
+// shown in reverse video. Otherwise, if JSON colors are set then the tokens of
+// the line are colored as JSON.
+func formatContentLine(opts formatOptions, gutter, line string) []string {
+	if opts.width < 1 {
+		return nil
 	}
-	m.processorCmdChan <- processor.Command{
-		Operation: processor.StartContentOperation,
-		Selector:  m.selectorModel.Value(),
-		Format:    m.formatModel.Value(),
-		Group:     selectedItemText,
-		Path:      m.path,
+	if strings.Contains(line, "\n") {
+		var rows []string
+		for i, row := range strings.Split(line, "\n") {
+			if i == 1 {
+				gutter = strings.Repeat(" ", ansi.StringWidth(gutter))
+			}
+			rows = append(rows, formatContentLine(opts, gutter, row)...)
+		}
+		return []string{strings.Join(rows, "\n")}
 	}
-	return nil
+	if opts.sanitize {
+		line = sanitizeLine(line)
+	}
+	line = expandTabs(line, opts.tabWidth)
+	if opts.json != (jsonColors{}) && opts.color == "" && !opts.selected {
+		line = colorizeJSON(line, opts.json)
+	}
+	line = gutter + line
+	if !opts.wrapped {
+		return []string{styleLine(opts, gutter, ansi.Truncate(line, opts.width, ""))}
+	}
+	line = ansi.Hardwrap(line, opts.width, true)
+	return []string{styleLine(opts, gutter, indentContinuations(line, opts))}
 }
 
-// formatContentLine returns the given line formatted with the given
-// characteristics.
-func formatContentLine(wrapped, lineNumbers bool, idx, width int, line string) []string {
-	if width < 1 {
-		return nil
+// contentLineRows returns the number of rows taken by the given line, prefixed
+// with the given gutter, when it is formatted by formatContentLine with the
+// given characteristics, without formatting it.
+func contentLineRows(opts formatOptions, gutter, line string) int {
+	if !opts.wrapped {
+		return strings.Count(line, "\n") + 1
 	}
-	if lineNumbers {
-		line = fmt.Sprintf("%5d: %s", idx, line)
+	if opts.width < 1 {
+		return 0
 	}
-	if !wrapped {
-		return []string{line[:min(len(line), width)]}
+	if !strings.Contains(line, "\n") && !opts.sanitize {
+		return wrappedRows(opts, gutter+expandTabs(line, opts.tabWidth))
+	}
+	rows := 0
+	for i, row := range strings.Split(line, "\n") {
+		if i == 1 {
+			gutter = strings.Repeat(" ", ansi.StringWidth(gutter))
+		}
+		if opts.sanitize {
+			row = sanitizeLine(row)
+		}
+		rows += wrappedRows(opts, gutter+expandTabs(row, opts.tabWidth))
+	}
+	return rows
+}
+
+// wrappedRows returns the number of rows taken by the given row of a line once
+// it is wrapped by formatContentLine. Rows of printable ASCII, as most log
+// lines are, are counted from their length, one column per byte. Other rows
+// are wrapped to count them.
+func wrappedRows(opts formatOptions, row string) int {
+	if !printableASCII(row) {
+		return strings.Count(indentContinuations(ansi.Hardwrap(row, opts.width, true), opts), "\n") + 1
+	}
+	indentWidth := ansi.StringWidth(opts.wrapIndent)
+	switch {
+	case len(row) <= opts.width:
+		return 1
+	case opts.wrapIndent == "" || indentWidth >= opts.width:
+		return (len(row) + opts.width - 1) / opts.width
+	}
+	rest, width := len(row)-opts.width, opts.width-indentWidth
+	return 1 + (rest+width-1)/width
+}
+
+// printableASCII returns true if the given string holds only printable ASCII
+// characters.
+func printableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < ' ' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// styleLine returns the given formatted line with everything after the given
+// gutter shown in the color of the given options, and in reverse video if it is
+// selected.
+func styleLine(opts formatOptions, gutter, line string) string {
+	if opts.color == "" && !opts.selected {
+		return line
+	}
+	rest, ok := strings.CutPrefix(line, gutter)
+	if !ok {
+		return line
+	}
+	style := lipgloss.NewStyle().Reverse(opts.selected)
+	if opts.color != "" {
+		style = style.Foreground(opts.color)
+	}
+	rows := strings.Split(rest, "\n")
+	for i, row := range rows {
+		rows[i] = style.Render(row)
+	}
+	return gutter + strings.Join(rows, "\n")
+}
+
+// indentContinuations prefixes the continuation rows of the given wrapped line
+// with the wrapIndent of the given options. The continuation rows are wrapped
+// again so that they fit the width once the indent is added.
+func indentContinuations(line string, opts formatOptions) string {
+	indentWidth := ansi.StringWidth(opts.wrapIndent)
+	first, rest, wrapped := strings.Cut(line, "\n")
+	if opts.wrapIndent == "" || !wrapped || indentWidth >= opts.width {
+		return line
+	}
+	rows := strings.Split(ansi.Hardwrap(strings.ReplaceAll(rest, "\n", ""), opts.width-indentWidth, true), "\n")
+	for i, row := range rows {
+		rows[i] = opts.wrapIndent + row
+	}
+	return first + "\n" + strings.Join(rows, "\n")
+}
+
+// expandTabs replaces each tab in the given line with enough spaces to reach
+// the next multiple of tabWidth columns. Columns are counted in display width,
+// so wide runes take two and ANSI escape sequences none, and start again after
+// each newline.
+func expandTabs(line string, tabWidth int) string {
+	if tabWidth < 1 || !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	column := 0
+	for {
+		i := strings.IndexAny(line, "\t\n")
+		if i < 0 {
+			b.WriteString(line)
+			return b.String()
+		}
+		b.WriteString(line[:i])
+		column += ansi.StringWidth(line[:i])
+		if line[i] == '\n' {
+			b.WriteByte('\n')
+			column = 0
+		} else {
+			spaces := tabWidth - column%tabWidth
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+		}
+		line = line[i+1:]
 	}
-	line = ansi.Hardwrap(line, width, true)
-	return []string{line}
 }
 
 // getGroupItems returns the groups represented by the groups map as a slice of
-// list.Item.
-func getGroupItems(groups map[string]struct{}) []list.Item {
+// list.Item, sorted. The given pinned groups that are present come first, after
+// "*", in the order they are given.
+func getGroupItems(groups map[string]struct{}, pinned []string) []list.Item {
 	var items []list.Item
+	first := map[string]bool{}
+	if len(pinned) > 0 {
+		for _, k := range append([]string{"*"}, pinned...) {
+			if _, ok := groups[k]; ok && !first[k] {
+				first[k] = true
+				items = append(items, item(k))
+			}
+		}
+	}
 	for _, k := range slices.Sorted(maps.Keys(groups)) {
-		items = append(items, item(k))
+		if !first[k] {
+			items = append(items, item(k))
+		}
 	}
 	return items
 }
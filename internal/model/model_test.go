@@ -0,0 +1,186 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// newTestModel returns a model of the given size holding the given lines of
+// content.
+func newTestModel(width, height int, lines []string) *Model {
+	m := NewModel(ModelOpts{Path: "test.json", TabWidth: 8})
+	m.rawOutputContent = lines
+	m.rawOutputRecords = make([]processor.Record, len(lines))
+	m.handleWindowSize(tea.WindowSizeMsg{Width: width, Height: height})
+	m.updateOutputModelContent()
+	return m
+}
+
+func TestDedupWrappedRepeats(t *testing.T) {
+	m := newTestModel(60, 30, nil)
+	m.wrap = true
+	m.dedup = true
+	line := strings.Repeat("repeated message ", 8)
+	m.handleProcessorContentLine(processor.ContentLine{Line: "first"})
+	for range 3 {
+		m.handleProcessorContentLine(processor.ContentLine{Line: line})
+	}
+	view := m.outputModel.View()
+	if got := strings.Count(view, "(x"); got != 1 {
+		t.Fatalf("repeat suffixes = %d, want 1:\n%s", got, view)
+	}
+	if !strings.Contains(view, "(x3)") {
+		t.Errorf("missing (x3):\n%s", view)
+	}
+	if got := strings.Count(view, "first"); got != 1 {
+		t.Errorf("first line shown %d times, want 1:\n%s", got, view)
+	}
+}
+
+func TestContentLineRowsMatchesFormat(t *testing.T) {
+	lines := []string{
+		"",
+		"short",
+		strings.Repeat("x", 59),
+		strings.Repeat("x", 60),
+		strings.Repeat("x", 61),
+		strings.Repeat("word ", 40),
+		"tab\there\tand\tthere " + strings.Repeat("y", 70),
+		"wide 日本語のテキスト " + strings.Repeat("日本", 30),
+		"\x1b[31mred\x1b[0m " + strings.Repeat("z", 100),
+		"two\nrows " + strings.Repeat("w", 80),
+		"control \x01 chars " + strings.Repeat("c", 60),
+	}
+	for n := 110; n < 250; n += 7 {
+		lines = append(lines, strings.Repeat("a b ", n/4+1)[:n])
+	}
+	for _, wrapIndent := range []string{"", "↳ "} {
+		for _, gutter := range []string{"", "  12: "} {
+			opts := formatOptions{wrapped: true, width: 60, tabWidth: 8, sanitize: true, wrapIndent: wrapIndent}
+			for _, line := range lines {
+				want := 0
+				for _, row := range formatContentLine(opts, gutter, line) {
+					want += strings.Count(row, "\n") + 1
+				}
+				if got := contentLineRows(opts, gutter, line); got != want {
+					t.Errorf("contentLineRows(%q, %q, indent %q) = %d, want %d", gutter, line, wrapIndent, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestShowOutputRowsFormatsVisibleRows(t *testing.T) {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d %s", i, strings.Repeat("x", i%70))
+	}
+	m := newTestModel(80, 20, lines)
+	m.wrap = true
+	m.updateOutputModelContent()
+	m.setOutputYOffset(37)
+	view := strings.Split(m.outputModel.View(), "\n")
+	if len(view) != m.outputModel.Height {
+		t.Fatalf("view has %d rows, want %d", len(view), m.outputModel.Height)
+	}
+	top := m.outputLines[m.outputLineAt(37)]
+	want := strings.Split(strings.Join(m.formatLine(top.idx, top.count), "\n"), "\n")[37-top.start]
+	if strings.TrimRight(view[0], " ") != strings.TrimRight(want, " ") {
+		t.Errorf("top row = %q, want %q", view[0], want)
+	}
+	m.outputGotoBottom()
+	if !m.outputAtBottom() || !strings.Contains(m.outputModel.View(), "line 99 ") {
+		t.Errorf("bottom of the output does not show the last line:\n%s", m.outputModel.View())
+	}
+}
+
+func TestExpandTabs(t *testing.T) {
+	const red, reset = "\x1b[31m", "\x1b[0m"
+	tests := []struct {
+		line     string
+		tabWidth int
+		want     string
+	}{
+		{"a\tb", 8, "a       b"},
+		{"\tb", 4, "    b"},
+		{"abcd\tb", 4, "abcd    b"},
+		{"ab\tc\td", 4, "ab  c   d"},
+		{"a\tb", 0, "a\tb"},
+		{"no tabs", 4, "no tabs"},
+		{"世\tb", 4, "世  b"},
+		{"世界x\tb", 4, "世界x   b"},
+		{red + "ab" + reset + "\tc", 4, red + "ab" + reset + "  c"},
+		{"ab\n\tc", 4, "ab\n    c"},
+	}
+	for _, test := range tests {
+		if got := expandTabs(test.line, test.tabWidth); got != test.want {
+			t.Errorf("expandTabs(%q, %d) = %q, want %q", test.line, test.tabWidth, got, test.want)
+		}
+	}
+}
+
+func TestEmptyContentShowsPlaceholderUntilFirstLine(t *testing.T) {
+	m := newTestModel(60, 30, nil)
+	m.handleProcessorContentStart(processor.ContentStart{FirstLine: 1})
+	if view := m.outputModel.View(); !strings.Contains(view, waitingPlaceholder) {
+		t.Fatalf("empty content view %q does not hold the placeholder", view)
+	}
+	m.handleProcessorContentLine(processor.ContentLine{Line: "first line", Record: processor.Record{Line: 1}})
+	view := m.outputModel.View()
+	if strings.Contains(view, waitingPlaceholder) || !strings.Contains(view, "first line") {
+		t.Errorf("view after the first line is %q", view)
+	}
+	if got := m.outputRowCount(); got != 1 {
+		t.Errorf("got %d output rows, want 1", got)
+	}
+}
+
+func TestContentLineAlerts(t *testing.T) {
+	m := newTestModel(60, 30, nil)
+	line := 0
+	send := func(alert, newRecord bool) {
+		if newRecord {
+			line++
+		}
+		m.handleProcessorContentLine(processor.ContentLine{Line: "line", Record: processor.Record{Line: line, Alert: alert}})
+	}
+
+	send(false, true)
+	if m.alertID != 0 {
+		t.Fatalf("a record that does not match raised an alert")
+	}
+	send(true, true)
+	if m.alertID != 1 {
+		t.Fatalf("got %d alerts, want 1", m.alertID)
+	}
+	// Matching records within the alertInterval are not alerted on.
+	send(true, true)
+	if m.alertID != 1 {
+		t.Errorf("got %d alerts within the interval, want 1", m.alertID)
+	}
+
+	// Alerts are raised while the context pane or other output is shown,
+	// but once per record.
+	m.lastAlert = time.Now().Add(-alertInterval)
+	m.showContext = true
+	send(true, true)
+	if m.alertID != 2 {
+		t.Errorf("got %d alerts with the context pane open, want 2", m.alertID)
+	}
+	m.showContext = false
+	m.lastAlert = time.Now().Add(-alertInterval)
+	send(true, false)
+	if m.alertID != 2 {
+		t.Errorf("the second line of a record raised an alert")
+	}
+	m.showExplain = true
+	send(true, true)
+	if m.alertID != 3 {
+		t.Errorf("got %d alerts with the output replaced, want 3", m.alertID)
+	}
+}
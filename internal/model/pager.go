@@ -0,0 +1,52 @@
+package model
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultPager is the command used to page output when neither a pager command
+// nor $PAGER is set.
+const defaultPager = "less"
+
+// pagerFinished is a tea.Msg that indicates the pager command has exited.
+type pagerFinished struct {
+	err error
+}
+
+// openPager returns a tea.Cmd that suspends the application and pipes the
+// currently loaded output content into the pager command. The command is run
+// by the shell so that it may include arguments and pipelines.
+func (m *Model) openPager() tea.Cmd {
+	pager := m.pagerCommand
+	if pager == "" {
+		pager = os.Getenv("PAGER")
+	}
+	if pager == "" {
+		pager = defaultPager
+	}
+	cmd := exec.Command("sh", "-c", pager)
+	var lines []string
+	for idx, line := range m.rawOutputContent {
+		if m.lineVisible(idx) {
+			lines = append(lines, line)
+		}
+	}
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n") + "\n")
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return pagerFinished{err: err}
+	})
+}
+
+// handlePagerFinished handles the pagerFinished message. The window size is
+// re-queried since the terminal may have been resized while the pager was
+// running. Any error from the pager is reported in the footer.
+func (m *Model) handlePagerFinished(msg pagerFinished) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		return m, tea.Batch(tea.WindowSize(), m.setStatus("pager: "+msg.err.Error()))
+	}
+	return m, tea.WindowSize()
+}
@@ -0,0 +1,46 @@
+package model
+
+import "strings"
+
+// pendingMarker replaces the end of the top border of a window whose value has
+// changed but has not yet been applied by the processor.
+const pendingMarker = "●"
+
+// appliedQuery holds the selector, format, and group of a request to the
+// processor.
+type appliedQuery struct {
+	selector string
+	format   string
+	group    string
+}
+
+// selectorPending returns true if the selector differs from the one the groups
+// were last read with.
+func (m *Model) selectorPending() bool {
+	return m.selectorModel.Value() != m.applied.selector
+}
+
+// formatPending returns true if the format differs from the one the content
+// was last read with.
+func (m *Model) formatPending() bool {
+	return m.formatModel.Value() != m.applied.format
+}
+
+// groupPending returns true if the selected group differs from the one the
+// content was last read or filtered for.
+func (m *Model) groupPending() bool {
+	return m.selectedGroup() != m.applied.group
+}
+
+// markPending returns the given bordered view with the pendingMarker at the
+// right end of its top border if pending is set.
+func markPending(view string, pending bool) string {
+	if !pending {
+		return view
+	}
+	top, rest, _ := strings.Cut(view, "\n")
+	if i := strings.LastIndex(top, "─┐"); i >= 0 {
+		top = top[:i] + pendingMarker + top[i+len("─"):]
+	}
+	return top + "\n" + rest
+}
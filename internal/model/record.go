@@ -0,0 +1,66 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// parseRecord parses the compact JSON of a record as sent by the processor. It
+// returns false if the record is empty or is not valid JSON.
+func parseRecord(record string) (any, bool) {
+	if record == "" {
+		return nil, false
+	}
+	var value any
+	if err := json.Unmarshal([]byte(record), &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// parseObject parses the compact JSON of a record as sent by the processor. It
+// returns false if the record is not a JSON object.
+func parseObject(record string) (map[string]any, bool) {
+	value, ok := parseRecord(record)
+	if !ok {
+		return nil, false
+	}
+	object, ok := value.(map[string]any)
+	return object, ok
+}
+
+// lookupField returns the value at the given dotted path (like ".a.b" or "a.b")
+// in the given parsed record. It returns false if any element of the path does
+// not exist.
+func lookupField(value any, path string) (any, bool) {
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return value, true
+	}
+	return lookupKeys(value, strings.Split(path, "."))
+}
+
+// lookupKeys returns the value at the given keys in the given parsed record.
+// Keys that are numbers index arrays, as in JSON pointers. It returns false if
+// any element of the path does not exist.
+func lookupKeys(value any, keys []string) (any, bool) {
+	for _, key := range keys {
+		switch container := value.(type) {
+		case map[string]any:
+			var ok bool
+			if value, ok = container[key]; !ok {
+				return nil, false
+			}
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(container) {
+				return nil, false
+			}
+			value = container[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
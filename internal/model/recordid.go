@@ -0,0 +1,104 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// recordIDLength is the number of hex digits of the hash shown as the ID of a
+// record.
+const recordIDLength = 8
+
+// cachedRecordID is the result of the last lookup made by lineRecordID.
+type cachedRecordID struct {
+	record processor.Record
+	id     string
+}
+
+// recordID returns the ID of the record with the given compact JSON: the first
+// recordIDLength hex digits of its SHA-256. The same record always has the
+// same ID, so IDs can be shared with others reading the same log.
+func recordID(json string) string {
+	sum := sha256.Sum256([]byte(json))
+	return hex.EncodeToString(sum[:])[:recordIDLength]
+}
+
+// lineRecordID returns the ID of the record of the loaded content line at the
+// given index, or "" if the line has no record.
+func (m *Model) lineRecordID(idx int) string {
+	if idx >= len(m.rawOutputRecords) || m.rawOutputRecords[idx].JSON == "" {
+		return ""
+	}
+	// Consecutive lines usually come from the same record, so the last
+	// result is reused rather than hashing the record again.
+	record := m.rawOutputRecords[idx]
+	if record != m.lastRecordID.record {
+		m.lastRecordID = cachedRecordID{record: record, id: recordID(record.JSON)}
+	}
+	return m.lastRecordID.id
+}
+
+// startRecordJump focuses the prompt for the ID of the record to jump to.
+func (m *Model) startRecordJump() tea.Cmd {
+	m.recordJumpModel.SetValue("")
+	return m.recordJumpModel.Focus()
+}
+
+// handleRecordJumpMessage handles messages sent to the record ID prompt. Enter
+// jumps to the record with the entered ID and esc cancels the jump. If the
+// records of the current content were not loaded then the content is reloaded
+// and the jump is made when the processor reports the new content.
+func (m *Model) handleRecordJumpMessage(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.recordJumpModel.Blur()
+			return m, nil
+		case "enter":
+			m.recordJumpModel.Blur()
+			id := strings.ToLower(strings.TrimSpace(m.recordJumpModel.Value()))
+			if id == "" {
+				return m, nil
+			}
+			if m.recordsLoaded {
+				return m, m.jumpToRecord(id)
+			}
+			m.recordJumpID = id
+			return m, m.reloadContent
+		}
+	}
+	m.recordJumpModel, cmd = m.recordJumpModel.Update(normalizePaste(msg))
+	return m, cmd
+}
+
+// finishRecordJump jumps to the record of a pending jump, if there is one.
+func (m *Model) finishRecordJump() tea.Cmd {
+	if m.recordJumpID == "" {
+		return nil
+	}
+	id := m.recordJumpID
+	m.recordJumpID = ""
+	return m.jumpToRecord(id)
+}
+
+// jumpToRecord scrolls the output window so that the first line of the first
+// record whose ID starts with the given prefix is at the top. The result is
+// reported in the footer.
+func (m *Model) jumpToRecord(prefix string) tea.Cmd {
+	pos := slices.IndexFunc(m.outputLines, func(line outputLine) bool {
+		return strings.HasPrefix(m.lineRecordID(line.idx), prefix)
+	})
+	if pos < 0 {
+		return m.setStatus(fmt.Sprintf("no record with ID %s in the output", prefix))
+	}
+	m.setOutputYOffset(m.outputLines[pos].start)
+	m.atBottom = m.outputAtBottom()
+	return m.setStatus(fmt.Sprintf("jumped to record %s", m.lineRecordID(m.topOutputLine())))
+}
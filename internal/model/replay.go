@@ -0,0 +1,122 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// replayCommand returns a jlv command line that opens the watched file with the
+// current selector, format, group, and display settings, so that the view can
+// be recreated later or by someone else. Options left at their defaults are
+// omitted.
+func (m *Model) replayCommand() string {
+	args := []string{"jlv"}
+	flag := func(set bool, name string) {
+		if set {
+			args = append(args, name)
+		}
+	}
+	option := func(name, value string) {
+		if value != "" {
+			args = append(args, name+"="+shellQuote(value))
+		}
+	}
+	option("--selector", m.selectorModel.Value())
+	flag(m.rawSelector, "--raw-selector")
+	flag(m.exists, "--exists")
+	flag(m.pointer, "--pointer")
+	if group := m.selectedGroup(); group != "*" {
+		option("--group", group)
+	}
+	option("--output", m.formatModel.Value())
+	option("--time-field", m.timeField)
+	if !m.since.IsZero() {
+		option("--since", m.since.Format(time.RFC3339Nano))
+	}
+	if !m.until.IsZero() {
+		option("--until", m.until.Format(time.RFC3339Nano))
+	}
+	switch m.gutter {
+	case gutterLineNumbers:
+		flag(true, "--linenumbers")
+	case gutterSourceLines:
+		flag(true, "--source-lines")
+	case gutterRecordIDs:
+		flag(true, "--record-ids")
+	case gutterDeltas:
+		flag(true, "--time-deltas")
+	}
+	flag(m.wrap, "--wrap")
+	option("--wrap-indent", m.wrapIndent)
+	flag(m.dedup, "--dedup")
+	flag(m.diffView, "--changes")
+	flag(m.lenient, "--lenient")
+	flag(m.sortKeys, "--sort-keys")
+	if m.indent > 0 {
+		option("--indent", strconv.Itoa(m.indent))
+	}
+	flag(m.tab, "--tab")
+	flag(m.relaxed, "--relaxed")
+	flag(m.sanitize, "--sanitize")
+	flag(m.noEnv, "--no-env")
+	flag(m.nulDelimited, "--nul-delimited")
+	flag(m.oneLine, "--one-line")
+	flag(m.hideNulls, "--hide-nulls")
+	flag(m.rawFallback, "--raw-fallback")
+	flag(m.colorJSON, "--color-json")
+	flag(m.liveGroups, "--live-groups")
+	flag(m.groupColors, "--group-colors")
+	for _, path := range m.redact {
+		option("--redact", path)
+	}
+	option("--mark", m.markPredicate)
+	option("--threshold-field", m.threshold.field)
+	option("--severity-field", m.severity.field)
+	if m.fromLine > 0 {
+		option("--from-line", strconv.Itoa(m.fromLine))
+	}
+	if m.lastLines > 0 {
+		option("--last-lines", strconv.Itoa(m.lastLines))
+	}
+	if m.encoding != "utf-8" {
+		option("--encoding", m.encoding)
+	}
+	if m.recordDelimiter != "" {
+		quoted := strconv.Quote(m.recordDelimiter)
+		option("--record-delimiter", quoted[1:len(quoted)-1])
+	}
+	option("--follow-group", string(m.groupFollow))
+	if m.groupsLayout != groupsLayoutList {
+		option("--groups-layout", string(m.groupsLayout))
+	}
+	flag(m.showTail, "--raw-tail")
+	flag(m.minimal, "--minimal")
+	args = append(args, shellQuote(m.source))
+	return strings.Join(args, " ")
+}
+
+// copyReplayCommand copies the command line returned by replayCommand to the
+// clipboard. The result is reported in the footer.
+func (m *Model) copyReplayCommand() tea.Cmd {
+	if err := clipboard.WriteAll(m.replayCommand()); err != nil {
+		return m.setStatus("copy command: " + err.Error())
+	}
+	if m.source == "-" {
+		return m.setStatus("copied a jlv command for this view to the clipboard (it reads stdin)")
+	}
+	return m.setStatus("copied a jlv command for this view to the clipboard")
+}
+
+// shellQuote returns the given string quoted for a POSIX shell. Strings made
+// only of characters that the shell does not treat specially are returned
+// unchanged.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:,=@%+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// sanitizeLine returns the given line with control characters, other than
+// tabs, replaced by a visible escape such as \x1b. This prevents escape
+// sequences in the watched file from moving the cursor or otherwise corrupting
+// the terminal.
+func sanitizeLine(line string) string {
+	if !strings.ContainsFunc(line, isUnsafeControl) {
+		return line
+	}
+	var b strings.Builder
+	for _, r := range line {
+		if !isUnsafeControl(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if r <= 0xff {
+			fmt.Fprintf(&b, "\\x%02x", r)
+		} else {
+			fmt.Fprintf(&b, "\\u%04x", r)
+		}
+	}
+	return b.String()
+}
+
+// isUnsafeControl returns whether the given rune is a control character that
+// should not be written to the terminal.
+func isUnsafeControl(r rune) bool {
+	return r != '\t' && (unicode.IsControl(r) || r == ' ' || r == ' ')
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// openScratch shows the scratch pane, where arbitrary jq programs can be run
+// over the file, in place of the selector window.
+func (m *Model) openScratch() tea.Cmd {
+	m.scratch = true
+	m.updateOutputModelContent()
+	return m.scratchModel.Focus()
+}
+
+// closeScratch hides the scratch pane and restores the content of the output
+// window.
+func (m *Model) closeScratch() {
+	m.scratch = false
+	m.scratchModel.Blur()
+	m.updateOutputModelContent()
+}
+
+// handleScratchMessage handles messages sent to the scratch pane. Enter runs the
+// program, esc closes the pane, and the up, down, page up, and page down keys
+// scroll the output window. Other keys edit the program.
+func (m *Model) handleScratchMessage(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.closeScratch()
+			return m, nil
+		case "enter":
+			if strings.TrimSpace(m.scratchModel.Value()) == "" {
+				return m, nil
+			}
+			m.scratchOutput = []string{"Running..."}
+			m.updateOutputModelContent()
+			return m, m.runScratch
+		case "up", "down", "pgup", "pgdown":
+			m.outputModel, cmd = m.outputModel.Update(msg)
+			return m, cmd
+		}
+	}
+	m.scratchModel, cmd = m.scratchModel.Update(normalizePaste(msg))
+	return m, cmd
+}
+
+// handleProcessorScratchResult handles the processor.ScratchResult message.
+// This message conveys the output of the scratch program, or the error from jq
+// if it failed. It is shown in the output window while the scratch pane is
+// open.
+func (m *Model) handleProcessorScratchResult(msg processor.ScratchResult) (tea.Model, tea.Cmd) {
+	m.scratchJq = msg.Jq
+	switch {
+	case msg.Err != nil:
+		m.scratchOutput = append([]string{msg.Err.Error()}, strings.Split(msg.Message, "\n")...)
+	case len(msg.Lines) == 0:
+		m.scratchOutput = []string{"(no output)"}
+	default:
+		m.scratchOutput = msg.Lines
+	}
+	m.outputModel.GotoTop()
+	m.updateOutputModelContent()
+	return m, nil
+}
+
+// scratchContent returns the output of the scratch program formatted for the
+// output window.
+func (m *Model) scratchContent() string {
+	var lines []string
+	for _, line := range m.scratchOutput {
+		lines = append(lines, formatContentLine(m.formatOptions(), "", line)...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runScratch is a tea.Cmd that issues a processor.RunScratchOperation to the
+// currently connected processor. It returns no message.
+func (m *Model) runScratch() tea.Msg {
+	m.processorCmdChan <- processor.Command{
+		Operation:    processor.RunScratchOperation,
+		Program:      m.scratchModel.Value(),
+		Path:         m.path,
+		SortKeys:     m.sortKeys,
+		Indent:       m.indent,
+		Tab:          m.tab,
+		MaxLineBytes: m.maxLineBytes,
+		NoEnv:        m.noEnv,
+	}
+	return nil
+}
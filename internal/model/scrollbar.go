@@ -0,0 +1,46 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// scrollbarWidth is the number of columns taken by the scrollbar to the right
+// of the output.
+const scrollbarWidth = 1
+
+// outputView returns the view of the output window's viewport with the
+// scrollbar to its right, below the raw tail pane if it is shown.
+func (m *Model) outputView() string {
+	output := lipgloss.JoinHorizontal(lipgloss.Top, m.outputModel.View(), m.scrollbarView())
+	if m.showTail {
+		return m.tailView() + "\n" + output
+	}
+	return output
+}
+
+// scrollbarView returns a column, as tall as the output window, with a thumb
+// whose position and size show the visible part of the output. Only the track
+// is shown if all of the output is visible.
+func (m *Model) scrollbarView() string {
+	height := m.outputModel.Height
+	if height < 1 {
+		return ""
+	}
+	track := lipgloss.NewStyle().Foreground(m.theme.muted).Render("░")
+	thumb := lipgloss.NewStyle().Foreground(m.theme.accent).Render("█")
+	total := m.outputTotalRows()
+	rows := make([]string, height)
+	for i := range rows {
+		rows[i] = track
+	}
+	if total > height {
+		thumbSize := max(1, height*height/total)
+		thumbTop := m.outputYOffset() * (height - thumbSize) / (total - height)
+		for i := thumbTop; i < min(thumbTop+thumbSize, height); i++ {
+			rows[i] = thumb
+		}
+	}
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// selectionHelp is shown in the footer while lines of the output are selected.
+const selectionHelp = "shift+up/down: extend selection  y: copy  v/esc: cancel"
+
+// selection is a range of output lines, identified by the indexes of their
+// cached content lines. The anchor is where the selection started and the end
+// moves as the selection is extended, so the end may come before the anchor.
+type selection struct {
+	active bool
+	anchor int
+	end    int
+}
+
+// contains returns true if the cached content line at the given index is in
+// the selection.
+func (s selection) contains(idx int) bool {
+	return s.active && idx >= min(s.anchor, s.end) && idx <= max(s.anchor, s.end)
+}
+
+// startSelection selects the line at the top of the output window.
+func (m *Model) startSelection() tea.Cmd {
+	top := m.topOutputLine()
+	if top < 0 {
+		return m.setStatus("nothing to select")
+	}
+	m.selection = selection{active: true, anchor: top, end: top}
+	m.updateOutputModelContent()
+	return nil
+}
+
+// clearSelection removes the highlight of the selected lines.
+func (m *Model) clearSelection() {
+	m.selection = selection{}
+	m.updateOutputModelContent()
+}
+
+// extendSelection moves the end of the selection by the given number of output
+// lines and scrolls the output window so that the end is visible.
+func (m *Model) extendSelection(delta int) {
+	lines := m.outputLineIndexes()
+	pos := slices.Index(lines, m.selection.end)
+	if pos < 0 {
+		return
+	}
+	m.selection.end = lines[min(max(pos+delta, 0), len(lines)-1)]
+	m.updateOutputModelContent()
+	m.scrollToOutputLine(m.selection.end)
+}
+
+// copySelection copies the selected lines, as output by jq, to the clipboard
+// and clears the selection. The result is reported in the footer.
+func (m *Model) copySelection() tea.Cmd {
+	var lines []string
+	for idx := min(m.selection.anchor, m.selection.end); idx <= max(m.selection.anchor, m.selection.end) && idx < len(m.rawOutputContent); idx++ {
+		if m.lineVisible(idx) {
+			lines = append(lines, m.rawOutputContent[idx])
+		}
+	}
+	m.clearSelection()
+	if err := clipboard.WriteAll(strings.Join(lines, "\n") + "\n"); err != nil {
+		return m.setStatus("copy lines: " + err.Error())
+	}
+	return m.setStatus(fmt.Sprintf("copied %d lines to the clipboard", len(lines)))
+}
+
+// outputLineIndexes returns the indexes of the cached content lines shown in
+// the output window, in order.
+func (m *Model) outputLineIndexes() []int {
+	lines := make([]int, len(m.outputLines))
+	for i, line := range m.outputLines {
+		lines[i] = line.idx
+	}
+	return lines
+}
+
+// topOutputLine returns the index of the cached content line at the top of
+// the output window, or -1 if there is none.
+func (m *Model) topOutputLine() int {
+	pos := m.outputLineAt(m.outputYOffset())
+	if pos == len(m.outputLines) {
+		return -1
+	}
+	return m.outputLines[pos].idx
+}
+
+// scrollToOutputLine scrolls the output window as little as possible so that
+// the cached content line at the given index is visible.
+func (m *Model) scrollToOutputLine(target int) {
+	for _, line := range m.outputLines {
+		if line.idx != target {
+			continue
+		}
+		if line.start < m.outputYOffset() {
+			m.setOutputYOffset(line.start)
+		} else if line.start+line.rows > m.outputYOffset()+m.outputModel.Height {
+			m.setOutputYOffset(line.start + line.rows - m.outputModel.Height)
+		}
+		break
+	}
+	m.atBottom = m.outputAtBottom()
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// severityCounts counts the loaded records by the value of a severity field.
+type severityCounts struct {
+	field  string
+	levels []string
+	counts []int
+	// values holds the first value seen for each level, as it appears in the
+	// records, so that it can be used as a group.
+	values []string
+	last   processor.Record
+}
+
+// newSeverityCounts returns a severityCounts that counts the given levels of
+// the given field.
+func newSeverityCounts(field string, levels []string) severityCounts {
+	return severityCounts{
+		field:  field,
+		levels: levels,
+		counts: make([]int, len(levels)),
+		values: make([]string, len(levels)),
+	}
+}
+
+// reset clears the counts.
+func (c *severityCounts) reset() {
+	*c = newSeverityCounts(c.field, c.levels)
+}
+
+// add counts the given record. Consecutive lines from the same record are only
+// counted once. A value matches a level if it starts with the level, ignoring
+// case, so that "warn" matches "WARNING".
+func (c *severityCounts) add(record processor.Record) {
+	if c.field == "" || record == c.last {
+		return
+	}
+	c.last = record
+	value, ok := parseRecord(record.JSON)
+	if !ok {
+		return
+	}
+	field, ok := lookupField(value, c.field)
+	if !ok {
+		return
+	}
+	severity, ok := field.(string)
+	if !ok {
+		return
+	}
+	for i, level := range c.levels {
+		if len(severity) >= len(level) && strings.EqualFold(severity[:len(level)], level) {
+			c.counts[i]++
+			if c.values[i] == "" {
+				c.values[i] = severity
+			}
+			return
+		}
+	}
+}
+
+// String returns the counts like "error: 12  warn: 40". An empty string is
+// returned if no field is configured.
+func (c severityCounts) String() string {
+	if c.field == "" {
+		return ""
+	}
+	parts := make([]string, len(c.levels))
+	for i, level := range c.levels {
+		parts[i] = fmt.Sprintf("%s: %d", level, c.counts[i])
+	}
+	return strings.Join(parts, "  ")
+}
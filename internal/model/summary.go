@@ -0,0 +1,41 @@
+package model
+
+import "strings"
+
+// querySummary returns a plain description of the current selector, group,
+// and format, like "level == error, showing .timestamp + .message", for users
+// who do not read jq.
+func (m *Model) querySummary() string {
+	selector := m.selectorModel.Value()
+	group := "*"
+	if selectedItem := m.groupsModel.SelectedItem(); selectedItem != nil {
+		group = selectedItem.FilterValue()
+	}
+	var filter string
+	switch {
+	case selector == "" || selector == ".":
+		filter = "all records"
+	case m.rawSelector:
+		filter = "records from " + selector
+	case m.exists && group == "true":
+		filter = "records where " + selector + " exists"
+	case m.exists && group == "false":
+		filter = "records where " + selector + " is missing"
+	case m.exists:
+		filter = "all records"
+	case group == "*":
+		filter = "records with " + selector
+	case m.arrayGroups:
+		filter = selector + " contains " + group
+	default:
+		filter = selector + " == " + group
+	}
+	if len(m.excludedGroups) > 0 && !m.rawSelector {
+		filter += ", excluding " + strings.Join(m.excludedGroups, ", ")
+	}
+	format := m.formatModel.Value()
+	if format == "" || format == "." {
+		return filter + ", showing whole records"
+	}
+	return filter + ", showing " + format
+}
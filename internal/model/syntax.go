@@ -0,0 +1,67 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// jsonColors holds the colors of the kinds of tokens of JSON. A zero
+// jsonColors leaves JSON uncolored.
+type jsonColors struct {
+	key     lipgloss.Color
+	str     lipgloss.Color
+	number  lipgloss.Color
+	literal lipgloss.Color
+}
+
+// colorizeJSON returns the given line of JSON, as jq prints it, with its keys,
+// strings, numbers, and the literals true, false, and null in the given
+// colors. Each line is colored on its own, which suits pretty-printed JSON as
+// no token spans lines. Anything that is not a token is left as it is.
+func colorizeJSON(line string, colors jsonColors) string {
+	var b strings.Builder
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == '"':
+			end := stringEnd(line, i)
+			color := colors.str
+			if strings.HasPrefix(strings.TrimLeft(line[end:], " "), ":") {
+				color = colors.key
+			}
+			b.WriteString(lipgloss.NewStyle().Foreground(color).Render(line[i:end]))
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(line) && strings.IndexByte("0123456789+-.eE", line[end]) >= 0 {
+				end++
+			}
+			b.WriteString(lipgloss.NewStyle().Foreground(colors.number).Render(line[i:end]))
+			i = end
+		case strings.HasPrefix(line[i:], "true") || strings.HasPrefix(line[i:], "false") || strings.HasPrefix(line[i:], "null"):
+			end := i + strings.IndexAny(line[i:]+",", ",]} ")
+			b.WriteString(lipgloss.NewStyle().Foreground(colors.literal).Render(line[i:end]))
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// stringEnd returns the index just past the end of the JSON string that
+// starts at the given index of the line, or the length of the line if the
+// string does not end on it.
+func stringEnd(line string, start int) int {
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(line)
+}
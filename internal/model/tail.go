@@ -0,0 +1,116 @@
+package model
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// maxTailLines is the number of lines kept for the raw tail pane.
+const maxTailLines = 1000
+
+// tailFormat is the format of the records shown in the raw tail pane: the
+// whole record on one line.
+const tailFormat = "tojson"
+
+// toggleTail shows or hides the raw tail pane above the output and starts or
+// stops the stream that feeds it.
+func (m *Model) toggleTail() tea.Cmd {
+	m.showTail = !m.showTail
+	m.tailLines = nil
+	m.handleWindowSize(tea.WindowSizeMsg{Height: m.height, Width: m.width})
+	if m.showTail {
+		return m.reloadTail
+	}
+	return m.stopTail
+}
+
+// splitTail takes the rows of the raw tail pane, and of the line separating it
+// from the output, from the height of the output window.
+func (m *Model) splitTail() {
+	if !m.showTail {
+		m.tailHeight = 0
+		return
+	}
+	m.tailHeight = m.outputModel.Height / 2
+	m.outputModel.Height -= m.tailHeight + 1
+}
+
+// tailView returns the newest lines of the raw tail pane, one row per line,
+// followed by a separator. Lines are truncated to the width of the output.
+func (m *Model) tailView() string {
+	width := m.outputModel.Width + scrollbarWidth
+	rows := make([]string, m.tailHeight)
+	lines := m.tailLines[max(len(m.tailLines)-m.tailHeight, 0):]
+	opts := m.formatOptions()
+	opts.wrapped = false
+	opts.width = width
+	for i, line := range lines {
+		rows[i] = formatContentLine(opts, "", line)[0]
+	}
+	separator := lipgloss.NewStyle().Foreground(m.theme.muted).Render(strings.Repeat("─", width))
+	return strings.Join(append(rows, separator), "\n")
+}
+
+// handleProcessorTailStart handles the processor.TailStart message. This
+// message conveys the lines read for the raw tail pane when the read started.
+func (m *Model) handleProcessorTailStart(msg processor.TailStart) (tea.Model, tea.Cmd) {
+	m.tailLines = nil
+	m.addTailLines(msg.InitialContent...)
+	return m, nil
+}
+
+// handleProcessorTailLine handles the processor.TailLine message. This message
+// conveys a new line for the raw tail pane.
+func (m *Model) handleProcessorTailLine(msg processor.TailLine) (tea.Model, tea.Cmd) {
+	m.addTailLines(msg.Line)
+	return m, nil
+}
+
+// handleProcessorTailError handles the processor.TailError message. The error
+// is shown in the raw tail pane.
+func (m *Model) handleProcessorTailError(msg processor.TailError) (tea.Model, tea.Cmd) {
+	m.tailLines = append([]string{msg.Err.Error()}, strings.Split(msg.Message, "\n")...)
+	return m, nil
+}
+
+// addTailLines adds the given lines to the raw tail pane, dropping the oldest
+// lines beyond maxTailLines.
+func (m *Model) addTailLines(lines ...string) {
+	m.tailLines = append(m.tailLines, lines...)
+	if extra := len(m.tailLines) - maxTailLines; extra > 0 {
+		m.tailLines = append(m.tailLines[:0], m.tailLines[extra:]...)
+	}
+}
+
+// reloadTail is a tea.Cmd that issues a processor.StartTailOperation to the
+// currently connected processor. The tail holds every record of the file,
+// regardless of the selector and group, one per line. It returns no message.
+func (m *Model) reloadTail() tea.Msg {
+	m.processorCmdChan <- processor.Command{
+		Operation:    processor.StartTailOperation,
+		Format:       tailFormat,
+		Group:        "*",
+		Path:         m.path,
+		MaxLineBytes: m.maxLineBytes,
+		Relaxed:      m.relaxed,
+		SortKeys:     m.sortKeys,
+		Lenient:      m.lenient,
+		FromLine:     m.fromLine,
+		Redact:       m.redact,
+		Encoding:     m.encoding,
+		NoEnv:        m.noEnv,
+	}
+	return nil
+}
+
+// stopTail is a tea.Cmd that issues a processor.StopTailOperation to the
+// currently connected processor. It returns no message.
+func (m *Model) stopTail() tea.Msg {
+	m.processorCmdChan <- processor.Command{
+		Operation: processor.StopTailOperation,
+	}
+	return nil
+}
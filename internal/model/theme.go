@@ -0,0 +1,75 @@
+package model
+
+import "github.com/charmbracelet/lipgloss"
+
+// theme holds the colors of the user interface.
+type theme struct {
+	// accent colors the border of the focused window and the scrollbar thumb.
+	accent lipgloss.Color
+	// muted colors the borders of the other windows, the scrollbar track, and
+	// separators.
+	muted lipgloss.Color
+	// alert colors the borders of invalid input and of alerts.
+	alert lipgloss.Color
+	// palette holds the colors given to groups when group colors are enabled.
+	palette []lipgloss.Color
+	// json colors the tokens of records when JSON colors are enabled.
+	json jsonColors
+}
+
+// darkTheme is the theme for terminals with a dark background. It is used
+// unless the theme is taken from the terminal.
+var darkTheme = theme{
+	accent: "#6CB0D2",
+	muted:  "#505050",
+	alert:  "#D25C5C",
+	palette: []lipgloss.Color{
+		"#6CB0D2",
+		"#D2A05C",
+		"#8CC26B",
+		"#C27BC9",
+		"#D2C65C",
+		"#5CC2B0",
+		"#D27C9A",
+		"#9A9AE0",
+	},
+	json: jsonColors{
+		key:     "#6CB0D2",
+		str:     "#8CC26B",
+		number:  "#D2A05C",
+		literal: "#C27BC9",
+	},
+}
+
+// lightTheme is the theme for terminals with a light background.
+var lightTheme = theme{
+	accent: "#1F6F99",
+	muted:  "#A8A8A8",
+	alert:  "#B03030",
+	palette: []lipgloss.Color{
+		"#1F6F99",
+		"#A0661A",
+		"#4E8A2E",
+		"#8E3E97",
+		"#8C7F14",
+		"#1F8C7A",
+		"#A8395F",
+		"#5151B8",
+	},
+	json: jsonColors{
+		key:     "#1F6F99",
+		str:     "#4E8A2E",
+		number:  "#A0661A",
+		literal: "#8E3E97",
+	},
+}
+
+// terminalTheme returns the theme that suits the background color of the
+// terminal. Terminals whose background cannot be detected are assumed to be
+// dark.
+func terminalTheme() theme {
+	if lipgloss.HasDarkBackground() {
+		return darkTheme
+	}
+	return lightTheme
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// thresholdSteps is the number of positions of the threshold slider between
+// the smallest and largest value of the threshold field.
+const thresholdSteps = 16
+
+// threshold is the state of the threshold slider. The slider picks a lower
+// bound for the numeric field from thresholdSteps positions spread evenly
+// between the smallest and largest value of the field in the file. Step 0
+// turns the threshold off.
+type threshold struct {
+	field   string
+	step    int
+	min     float64
+	max     float64
+	ranged  bool
+	ranging bool
+	pending int
+}
+
+// value returns the lower bound picked by the slider.
+func (t threshold) value() float64 {
+	return t.min + float64(t.step-1)*(t.max-t.min)/float64(thresholdSteps-1)
+}
+
+// active returns true if the threshold filters the content.
+func (t threshold) active() bool {
+	return t.field != "" && t.step > 0
+}
+
+// moveThreshold moves the slider by the given number of steps and reloads the
+// content once no other move has been made for the reload debounce interval.
+// The range of the field is read first if it is not known yet.
+func (m *Model) moveThreshold(delta int) tea.Cmd {
+	if m.threshold.field == "" {
+		return m.setStatus("no threshold field, start with --threshold-field")
+	}
+	if !m.threshold.ranged {
+		m.threshold.pending += delta
+		if m.threshold.ranging {
+			return nil
+		}
+		m.threshold.ranging = true
+		return tea.Batch(m.setStatus("reading the range of "+m.threshold.field), m.readThresholdRange)
+	}
+	step := min(max(m.threshold.step+delta, 0), thresholdSteps)
+	if step == m.threshold.step {
+		return nil
+	}
+	m.threshold.step = step
+	return m.debounceReload(formatWindow)
+}
+
+// readThresholdRange is a tea.Cmd that issues a processor.RunHistogramOperation
+// with a single bucket for the threshold field, whose minimum and maximum are
+// the range of the slider. It returns no message.
+func (m *Model) readThresholdRange() tea.Msg {
+	m.processorCmdChan <- processor.Command{
+		Operation: processor.RunHistogramOperation,
+		Format:    m.threshold.field,
+		Group:     "*",
+		Path:      m.path,
+		Redact:    m.redact,
+		Buckets:   1,
+		NoEnv:     m.noEnv,
+	}
+	return nil
+}
+
+// handleThresholdRange sets the range of the slider from the given histogram of
+// the threshold field and makes the moves made while it was read.
+func (m *Model) handleThresholdRange(msg processor.Histogram) tea.Cmd {
+	m.threshold.ranging = false
+	delta := m.threshold.pending
+	m.threshold.pending = 0
+	switch {
+	case msg.Err != nil:
+		return m.setStatus("threshold range: " + msg.Err.Error())
+	case len(msg.Counts) == 0:
+		return m.setStatus("no numeric values of " + m.threshold.field)
+	}
+	m.threshold.min, m.threshold.max = msg.Min, msg.Max
+	m.threshold.ranged = true
+	m.status = ""
+	cmd := m.moveThreshold(delta)
+	if m.showHistogram {
+		// The range was read in place of the histogram.
+		return tea.Batch(cmd, m.openHistogram())
+	}
+	return cmd
+}
+
+// thresholdStatus returns the slider and the lower bound it picks for the
+// footer, or "" if the threshold is off.
+func (m *Model) thresholdStatus() string {
+	if !m.threshold.active() {
+		return ""
+	}
+	slider := strings.Repeat("█", m.threshold.step) + strings.Repeat("░", thresholdSteps-m.threshold.step)
+	return fmt.Sprintf("%s >= %.4g %s", m.threshold.field, m.threshold.value(), slider)
+}
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// sparkBlocks are the characters of a sparkline, from the fewest records to
+// the most. Buckets without records are blank.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// timelineTimeLayout is the layout of the times at the ends of the timeline.
+const timelineTimeLayout = "2006-01-02 15:04:05"
+
+// openTimeline shows a sparkline of the number of selected records over time,
+// according to the time field, in place of the output. There is one bucket
+// per column of the output window at first.
+func (m *Model) openTimeline() tea.Cmd {
+	if m.timeField == "" {
+		return m.setStatus("the timeline needs --time-field")
+	}
+	if m.showHistogram {
+		m.closeHistogram()
+	}
+	if m.showContext {
+		m.closeContext()
+	}
+	if m.timelineBuckets == 0 {
+		m.timelineBuckets = max(m.outputModel.Width, 1)
+	}
+	m.showTimeline = true
+	m.timeline = processor.Timeline{}
+	m.updateOutputModelContent()
+	return m.runTimeline
+}
+
+// closeTimeline hides the timeline and restores the content of the output
+// window.
+func (m *Model) closeTimeline() {
+	m.showTimeline = false
+	m.updateOutputModelContent()
+}
+
+// resizeTimelineBuckets doubles the number of buckets of the timeline, making
+// each bucket span half as long, or halves it if finer is false, and buckets
+// the records again. There are at most as many buckets as columns of the
+// output window.
+func (m *Model) resizeTimelineBuckets(finer bool) tea.Cmd {
+	if finer {
+		m.timelineBuckets = min(m.timelineBuckets*2, max(m.outputModel.Width, 1))
+	} else {
+		m.timelineBuckets = max(m.timelineBuckets/2, 1)
+	}
+	return m.openTimeline()
+}
+
+// handleProcessorTimeline handles the processor.Timeline message. This message
+// conveys the number of records in each time bucket, or the error from jq if
+// it failed. It is shown in the output window while the timeline is open.
+func (m *Model) handleProcessorTimeline(msg processor.Timeline) (tea.Model, tea.Cmd) {
+	if !m.showTimeline {
+		return m, nil
+	}
+	m.timeline = msg
+	m.outputModel.GotoTop()
+	m.updateOutputModelContent()
+	return m, nil
+}
+
+// timelineContent returns the timeline formatted for the output window: a
+// sparkline stretched to the width of the window, the times at its ends, and
+// the size of the buckets.
+func (m *Model) timelineContent() string {
+	switch {
+	case m.timeline.Err != nil:
+		return m.timeline.Err.Error() + "\n" + m.timeline.Message
+	case m.timeline.Jq == "":
+		return "Running..."
+	case len(m.timeline.Counts) == 0:
+		return "(no records with a time in " + m.timeField + ")"
+	}
+	counts := m.timeline.Counts
+	total, most := 0, 0
+	for _, count := range counts {
+		total += count
+		most = max(most, count)
+	}
+	cell := max(m.outputModel.Width/len(counts), 1)
+	var spark strings.Builder
+	for _, count := range counts {
+		block := " "
+		if count > 0 {
+			block = string(sparkBlocks[(count*len(sparkBlocks)-1)/most])
+		}
+		spark.WriteString(strings.Repeat(block, cell))
+	}
+	start := m.timeline.Start.Local().Format(timelineTimeLayout)
+	end := m.timeline.End.Local().Format(timelineTimeLayout)
+	gap := max(cell*len(counts)-len(start)-len(end), 1)
+	bucket := m.timeline.End.Sub(m.timeline.Start) / time.Duration(len(counts))
+	return strings.Join([]string{
+		spark.String(),
+		start + strings.Repeat(" ", gap) + end,
+		"",
+		fmt.Sprintf("%d records in %d buckets of %s, at most %d in a bucket", total, len(counts), strings.TrimPrefix(formatDelta(bucket), "+"), most),
+	}, "\n")
+}
+
+// timelineHelp returns the text shown in the footer while the timeline is
+// open.
+func (m *Model) timelineHelp() string {
+	return "+/-: finer/coarser buckets  esc: close  " + m.timeline.Jq
+}
+
+// runTimeline is a tea.Cmd that issues a processor.RunTimelineOperation for the
+// selected group to the currently connected processor. It returns no message.
+func (m *Model) runTimeline() tea.Msg {
+	m.processorCmdChan <- processor.Command{
+		Operation:      processor.RunTimelineOperation,
+		Selector:       m.selectorModel.Value(),
+		Group:          m.selectedGroup(),
+		ExcludedGroups: m.excludedGroups,
+		Path:           m.path,
+		RawSelector:    m.rawSelector,
+		Exists:         m.exists,
+		ArrayGroups:    m.arrayGroups,
+		Pointer:        m.pointer,
+		Redact:         m.redact,
+		Relaxed:        m.relaxed,
+		Buckets:        m.timelineBuckets,
+		TimeField:      m.timeField,
+		Since:          m.since,
+		Until:          m.until,
+		NoEnv:          m.noEnv,
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// recordTimestamp returns the time held in the given field of the given record.
+// It returns false if the record does not have the field or the field cannot be
+// interpreted as a time.
+func recordTimestamp(record, field string) (time.Time, bool) {
+	value, ok := parseRecord(record)
+	if !ok {
+		return time.Time{}, false
+	}
+	value, ok = lookupField(value, field)
+	if !ok {
+		return time.Time{}, false
+	}
+	return processor.ParseTimestamp(value)
+}
+
+// timeRange tracks the earliest and latest timestamps seen.
+type timeRange struct {
+	min time.Time
+	max time.Time
+}
+
+// add extends the range to include the given time.
+func (r *timeRange) add(t time.Time) {
+	if r.min.IsZero() || t.Before(r.min) {
+		r.min = t
+	}
+	if r.max.IsZero() || t.After(r.max) {
+		r.max = t
+	}
+}
+
+// String returns the range in local time like "2024-01-01 10:00 → 10:45". The
+// date is repeated for the end of the range if it differs from the start. An
+// empty string is returned if no times have been added.
+func (r timeRange) String() string {
+	if r.min.IsZero() {
+		return ""
+	}
+	minTime, maxTime := r.min.Local(), r.max.Local()
+	start := minTime.Format("2006-01-02 15:04")
+	if minTime.Format(time.DateOnly) == maxTime.Format(time.DateOnly) {
+		return start + " → " + maxTime.Format("15:04")
+	}
+	return start + " → " + maxTime.Format("2006-01-02 15:04")
+}
+
+// cachedTimestamp is the result of a lookup made by recordTime.
+type cachedTimestamp struct {
+	record processor.Record
+	time   time.Time
+	ok     bool
+}
+
+// recordTime returns the time held in the time field of the given record. The
+// last two results are kept because deltas compare each record with the one
+// before it.
+func (m *Model) recordTime(record processor.Record) (time.Time, bool) {
+	for _, cached := range m.recordTimes {
+		if cached.record == record {
+			return cached.time, cached.ok
+		}
+	}
+	t, ok := recordTimestamp(record.JSON, m.timeField)
+	m.recordTimes[1] = m.recordTimes[0]
+	m.recordTimes[0] = cachedTimestamp{record: record, time: t, ok: ok}
+	return t, ok
+}
+
+// timeDelta returns the time between the record of the cached content line at
+// the given index and the record of the previous line shown in the output,
+// like "+120ms". It returns "" for the lines after the first of a record and
+// when either record lacks a timestamp.
+func (m *Model) timeDelta(idx int) string {
+	record := m.rawOutputRecords[idx]
+	prev := idx - 1
+	for prev >= 0 && !m.lineVisible(prev) {
+		prev--
+	}
+	if prev < 0 || m.rawOutputRecords[prev] == record {
+		return ""
+	}
+	t, ok := m.recordTime(record)
+	if !ok {
+		return ""
+	}
+	prevTime, ok := m.recordTime(m.rawOutputRecords[prev])
+	if !ok {
+		return ""
+	}
+	return formatDelta(t.Sub(prevTime))
+}
+
+// formatDelta returns the given duration with a sign, in milliseconds below a
+// second, in tenths of a second below a minute, in minutes and seconds below an
+// hour, in tenths of an hour below a day, and in days above.
+func formatDelta(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%s%dms", sign, d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%s%.1fs", sign, d.Seconds())
+	case d < time.Hour:
+		return sign + d.Round(time.Second).String()
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%s%.1fh", sign, d.Hours())
+	}
+	return fmt.Sprintf("%s%.0fd", sign, d.Hours()/24)
+}
@@ -0,0 +1,88 @@
+package model
+
+import "unicode"
+
+// plausibleExpression performs a lightweight syntax check of the given jq
+// expression. It returns false if brackets or quotes are unbalanced or the
+// expression ends in a '.' after a field or index, like ".a." or ".[0].",
+// which jq would reject. The recurse operator ".." and comments from '#' to
+// the end of the line are allowed. It does not guarantee that jq will accept
+// the expression.
+func plausibleExpression(expr string) bool {
+	// The stack holds the open brackets. A '"' on the stack marks a string
+	// interpolation, "\(...)", that returns to the string when closed.
+	var stack []rune
+	inString := false
+	inComment := false
+	escaped := false
+	// The last two runes of code outside strings and comments, other than
+	// spaces, for the check of a trailing '.'.
+	var prev, last rune
+	// numeric is set if the last word of code is a number, like "1", which
+	// may end in a '.', rather than a field name, like "a1".
+	numeric := false
+	for _, r := range expr {
+		if inComment {
+			inComment = r != '\n'
+			continue
+		}
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+				if r == '(' {
+					stack = append(stack, '"')
+					inString = false
+				}
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				inString = false
+				prev, last = last, r
+			}
+			continue
+		}
+		if !unicode.IsSpace(r) && r != '#' {
+			switch {
+			case unicode.IsDigit(r) && !isWordRune(last):
+				numeric = true
+			case unicode.IsLetter(r) || r == '_':
+				numeric = false
+			}
+			prev, last = last, r
+		}
+		switch r {
+		case '#':
+			inComment = true
+		case '"':
+			inString = true
+		case '(', '[', '{':
+			stack = append(stack, r)
+		case ')', ']', '}':
+			if len(stack) == 0 {
+				return false
+			}
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			switch {
+			case open == '"' && r == ')':
+				inString = true
+			case open == '(' && r == ')', open == '[' && r == ']', open == '{' && r == '}':
+			default:
+				return false
+			}
+		}
+	}
+	// A '.' after a field or index is never valid, unlike the '.' of the
+	// recurse operator ".." or of a number.
+	if last == '.' && (prev == ']' || prev == '"' || (isWordRune(prev) && !numeric)) {
+		return false
+	}
+	return !inString && len(stack) == 0
+}
+
+// isWordRune returns true if the given rune can be part of a field name or
+// number.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,52 @@
+package model
+
+import "testing"
+
+func TestPlausibleExpression(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"", true},
+		{".", true},
+		{".a", true},
+		{".a.b", true},
+		{".a.", false},
+		{".a[0].", false},
+		{`.["a"].`, false},
+		{`."a".`, false},
+		{".a . ", false},
+		{".a1.", false},
+		{"1.", true},
+		{". + 1.", true},
+		{"..", true},
+		{".a|..", true},
+		{"..|numbers", true},
+		{".a | .", true},
+		{"(.a)", true},
+		{"(.a", false},
+		{".a)", false},
+		{"[.a, {b: .c}]", true},
+		{"[.a}", false},
+		{"{a: [1, 2}]", false},
+		{`"a"`, true},
+		{`"a`, false},
+		{`"a\"b"`, true},
+		{`"(" + .a`, true},
+		{`"\(.a)"`, true},
+		{`"\(.a"`, false},
+		{`"x \("y \(.a)") z"`, true},
+		{`"\(.a | "(")"`, true},
+		{".a # trailing comment", true},
+		{".a # unbalanced ( and \" in a comment", true},
+		{".a # comment\n| (.b", false},
+		{".a. # comment", false},
+		{".a # comment\n| .b", true},
+		{`"#" + .a`, true},
+	}
+	for _, test := range tests {
+		if got := plausibleExpression(test.expr); got != test.want {
+			t.Errorf("plausibleExpression(%q) = %v, want %v", test.expr, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package model
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The lines of the content are indexed in outputLines by the rows of the output
+// window they take, and only the lines that fit in the output window are
+// formatted and set as the content of the viewport, so that adding a line or
+// scrolling does not format or hand the whole buffer to the viewport. The model
+// keeps the scroll position of the content in outputOffset and scrolls it with
+// the viewport's key bindings. Views that replace the content, like the
+// histogram, are set on the viewport whole and scrolled by it.
+
+// outputLine is a line of the output window: the index of a visible cached
+// content line, the number of times it is repeated, the row of the output it
+// starts at, and the number of rows it takes.
+type outputLine struct {
+	idx   int
+	count int
+	start int
+	rows  int
+}
+
+// outputReplaced returns true if a view other than the content is shown in the
+// output window.
+func (m *Model) outputReplaced() bool {
+	return m.scratch || m.showHistogram || m.showTimeline || m.browsing || m.showContext || m.showExamples || m.showExplain
+}
+
+// appendOutputLine adds the cached content line at the given index, repeated
+// the given number of times, to the end of the lines of the output window.
+func (m *Model) appendOutputLine(idx, count int) {
+	m.outputLines = append(m.outputLines, outputLine{idx: idx, count: count, start: m.outputRowCount(), rows: m.lineRows(idx, count)})
+}
+
+// outputRowCount returns the number of rows taken by the lines of the output
+// window.
+func (m *Model) outputRowCount() int {
+	if len(m.outputLines) == 0 {
+		return 0
+	}
+	last := m.outputLines[len(m.outputLines)-1]
+	return last.start + last.rows
+}
+
+// outputLineAt returns the position in outputLines of the line that takes the
+// given row, or len(outputLines) if no line does.
+func (m *Model) outputLineAt(row int) int {
+	return sort.Search(len(m.outputLines), func(i int) bool {
+		return m.outputLines[i].start+m.outputLines[i].rows > row
+	})
+}
+
+// showOutputRows formats the lines that take the rows from the scroll position
+// that fit in the output window and sets those rows as the content of the
+// viewport. If the output is followed then the scroll position is moved to the
+// bottom first.
+func (m *Model) showOutputRows() {
+	if m.atBottom {
+		m.outputOffset = m.maxOutputOffset()
+	}
+	m.outputOffset = min(max(m.outputOffset, 0), m.maxOutputOffset())
+	var rows []string
+	first := m.outputLineAt(m.outputOffset)
+	skip := 0
+	if first < len(m.outputLines) {
+		skip = m.outputOffset - m.outputLines[first].start
+	}
+	end := first
+	for ; end < len(m.outputLines) && len(rows) < skip+m.outputModel.Height; end++ {
+		line := m.outputLines[end]
+		for _, formatted := range m.formatLine(line.idx, line.count) {
+			rows = append(rows, strings.Split(formatted, "\n")...)
+		}
+	}
+	m.pruneFormatCache(first, end)
+	rows = rows[min(skip, len(rows)):min(skip+m.outputModel.Height, len(rows))]
+	m.outputModel.SetContent(strings.Join(rows, "\n"))
+	m.outputModel.SetYOffset(0)
+}
+
+// maxOutputOffset returns the scroll position that shows the last rows of the
+// content.
+func (m *Model) maxOutputOffset() int {
+	return max(0, m.outputRowCount()-m.outputModel.Height)
+}
+
+// outputYOffset returns the index of the row at the top of the output window.
+func (m *Model) outputYOffset() int {
+	if m.outputReplaced() {
+		return m.outputModel.YOffset
+	}
+	return m.outputOffset
+}
+
+// setOutputYOffset scrolls the output window so that the row at the given
+// index is at the top, as far as the rows allow.
+func (m *Model) setOutputYOffset(n int) {
+	if m.outputReplaced() {
+		m.outputModel.SetYOffset(n)
+		return
+	}
+	m.outputOffset = n
+	m.atBottom = false
+	m.showOutputRows()
+	m.atBottom = m.outputAtBottom()
+}
+
+// outputTotalRows returns the number of rows in the output window's content.
+func (m *Model) outputTotalRows() int {
+	if m.outputReplaced() {
+		return m.outputModel.TotalLineCount()
+	}
+	return m.outputRowCount()
+}
+
+// outputAtBottom returns true if the last row of the output is visible.
+func (m *Model) outputAtBottom() bool {
+	if m.outputReplaced() {
+		return m.outputModel.AtBottom()
+	}
+	return m.outputOffset >= m.maxOutputOffset()
+}
+
+// outputScrollPercent returns how far the output window is scrolled, from 0 at
+// the top to 1 at the bottom, like viewport.Model.ScrollPercent.
+func (m *Model) outputScrollPercent() float64 {
+	if m.outputReplaced() {
+		return m.outputModel.ScrollPercent()
+	}
+	if m.outputModel.Height >= m.outputRowCount() {
+		return 1.0
+	}
+	return min(max(float64(m.outputOffset)/float64(m.maxOutputOffset()), 0), 1)
+}
+
+// outputGotoTop scrolls the output window to its first row.
+func (m *Model) outputGotoTop() {
+	m.atBottom = false
+	if m.outputReplaced() {
+		m.outputModel.GotoTop()
+		return
+	}
+	m.outputOffset = 0
+	m.showOutputRows()
+}
+
+// outputGotoBottom scrolls the output window to its last row and follows new
+// content.
+func (m *Model) outputGotoBottom() {
+	m.atBottom = true
+	if m.outputReplaced() {
+		m.outputModel.GotoBottom()
+		return
+	}
+	m.showOutputRows()
+}
+
+// scrollOutput scrolls the content for the given message using the key
+// bindings and mouse wheel settings of the viewport.
+func (m *Model) scrollOutput(msg tea.Msg) {
+	delta := 0
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		keys := m.outputModel.KeyMap
+		switch {
+		case key.Matches(msg, keys.PageDown):
+			delta = m.outputModel.Height
+		case key.Matches(msg, keys.PageUp):
+			delta = -m.outputModel.Height
+		case key.Matches(msg, keys.HalfPageDown):
+			delta = m.outputModel.Height / 2
+		case key.Matches(msg, keys.HalfPageUp):
+			delta = -m.outputModel.Height / 2
+		case key.Matches(msg, keys.Down):
+			delta = 1
+		case key.Matches(msg, keys.Up):
+			delta = -1
+		}
+	case tea.MouseMsg:
+		if !m.outputModel.MouseWheelEnabled || msg.Action != tea.MouseActionPress {
+			break
+		}
+		switch msg.Button {
+		case tea.MouseButtonWheelDown:
+			delta = m.outputModel.MouseWheelDelta
+		case tea.MouseButtonWheelUp:
+			delta = -m.outputModel.MouseWheelDelta
+		}
+	}
+	if delta == 0 {
+		return
+	}
+	m.setOutputYOffset(m.outputOffset + delta)
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// benchmarkLines returns n lines of content like those of a log.
+func benchmarkLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf(`{"ts":"2024-05-01T12:00:%02d Z","level":"info","n":%d,"message":"request handled in %dms by worker %d"}`, i%60, i, i%500, i%16)
+	}
+	return lines
+}
+
+func benchmarkResize(b *testing.B, wrap bool) {
+	m := newTestModel(150, 40, benchmarkLines(100000))
+	m.wrap = wrap
+	m.updateOutputModelContent()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.handleWindowSize(tea.WindowSizeMsg{Width: 120 + i%60, Height: 40})
+	}
+}
+
+func BenchmarkResize100k(b *testing.B) {
+	benchmarkResize(b, false)
+}
+
+func BenchmarkResize100kWrapped(b *testing.B) {
+	benchmarkResize(b, true)
+}
+
+func TestFormatCacheKeepsVisibleLines(t *testing.T) {
+	m := newTestModel(150, 40, benchmarkLines(10000))
+	m.outputGotoTop()
+	m.setOutputYOffset(5000)
+	if len(m.formatCache) > 2*formatCacheMargin+m.outputModel.Height {
+		t.Errorf("format cache holds %d lines, want at most %d", len(m.formatCache), 2*formatCacheMargin+m.outputModel.Height)
+	}
+	if _, ok := m.formatCache[5000]; !ok {
+		t.Error("format cache does not hold the line at the top of the output window")
+	}
+}
@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ContextRange is a run of consecutive lines of the file.
+type ContextRange struct {
+	// Start is the line number of the first line of the range.
+	Start int
+	Lines []string
+}
+
+// ContextLines is a tea.Msg that conveys the lines of the file around the
+// lines of a RunContextOperation. Overlapping and adjacent ranges are merged.
+type ContextLines struct {
+	Ranges []ContextRange
+	Err    error
+}
+
+// contextSpans returns the first and last line numbers of the ranges of the
+// given number of lines before and after each of the given line numbers, in
+// order. Overlapping and adjacent ranges are merged.
+func contextSpans(lines []int, size int) [][2]int {
+	lines = slices.Clone(lines)
+	slices.Sort(lines)
+	var spans [][2]int
+	for _, line := range slices.Compact(lines) {
+		if line < 1 {
+			continue
+		}
+		first, last := max(line-size, 1), line+size
+		if n := len(spans); n > 0 && first <= spans[n-1][1]+1 {
+			spans[n-1][1] = max(spans[n-1][1], last)
+			continue
+		}
+		spans = append(spans, [2]int{first, last})
+	}
+	return spans
+}
+
+// readContextRanges returns the lines of the given file in the given spans,
+// as returned by contextSpans. The file is read once, up to the end of the
+// last span. Spans that extend past the end of the file are cut short.
+func readContextRanges(ctx context.Context, path string, spans [][2]int) ([]ContextRange, error) {
+	if len(spans) == 0 {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	ranges := make([]ContextRange, 0, len(spans))
+	span := 0
+	for number := 1; span < len(spans); number++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if number < spans[span][0] {
+			continue
+		}
+		if number == spans[span][0] {
+			ranges = append(ranges, ContextRange{Start: number})
+		}
+		current := &ranges[len(ranges)-1]
+		current.Lines = append(current.Lines, strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+		if number == spans[span][1] {
+			span++
+		}
+	}
+	return ranges, nil
+}
+
+// readContext sends the lines of the file around the lines of the given
+// streamArgs to the program as a ContextLines message. Nothing is sent if the
+// run is canceled.
+func readContext(args streamArgs) {
+	ranges, err := readContextRanges(args.ctx, args.cmd.Path, contextSpans(args.cmd.Lines, args.cmd.Context))
+	if args.ctx.Err() != nil {
+		return
+	}
+	args.program.Send(ContextLines{Ranges: ranges, Err: err})
+}
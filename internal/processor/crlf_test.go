@@ -0,0 +1,105 @@
+package processor
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const crlfFixture = "../../testdata/crlf.json"
+
+func TestSplitLinesCRLF(t *testing.T) {
+	content, err := os.ReadFile(crlfFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "\r\n") {
+		t.Fatal("the fixture does not have CRLF line endings")
+	}
+	lines := splitLines(content)
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "\r") {
+			t.Errorf("line %q holds a carriage return", line)
+		}
+	}
+}
+
+func TestInputFilterCRLF(t *testing.T) {
+	if filter := inputFilter(Command{Path: writeTestFile(t, "{}\n{}\n")}, nil); filter != nil {
+		t.Error("a file with LF line endings is filtered")
+	}
+	filter := inputFilter(Command{Path: crlfFixture}, nil)
+	if filter == nil {
+		t.Fatal("a file with CRLF line endings is not filtered")
+	}
+	if got := filter("{}\r"); got != "{}" {
+		t.Errorf("got %q, want %q", got, "{}")
+	}
+}
+
+func TestReadContentLinesCRLF(t *testing.T) {
+	if _, err := exec.LookPath("jq"); err != nil {
+		t.Skip("jq not found")
+	}
+	args := streamArgs{ctx: context.Background(), program: &testSender{}, cmd: Command{Path: crlfFixture}, truncated: &atomic.Bool{}}
+	// The query shows each input line as jq reads it.
+	inputs, count, _, err := readContentLines(args, "tojson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || len(inputs) != 3 {
+		t.Fatalf("got %d lines read and %d inputs, want 3", count, len(inputs))
+	}
+	for _, input := range inputs {
+		if strings.Contains(input, `\r`) {
+			t.Errorf("jq input %s holds a carriage return", input)
+		}
+	}
+	cmd := Command{Path: crlfFixture, Format: ".level", Group: "*"}
+	args.cmd = cmd
+	lines, _, _, err := readContentLines(args, createJQContentQuery(cmd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(lines, ",") != "error,trace,info" {
+		t.Errorf("got %q, want the levels of the records", lines)
+	}
+}
+
+func TestFollowedLinesCRLF(t *testing.T) {
+	if _, err := exec.LookPath("jq"); err != nil {
+		t.Skip("jq not found")
+	}
+	// cat stands in for tail -f, whose output reaches jq the same way.
+	catCmd := exec.Command("cat", crlfFixture)
+	jqCmd := exec.Command("jq", "-R", "tojson")
+	stdout, err := join(inputFilter(Command{Path: crlfFixture}, nil), catCmd, jqCmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := start(catCmd, jqCmd); err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jqCmd.Wait()
+	catCmd.Wait()
+	inputs := splitLines(output)
+	if len(inputs) != 3 {
+		t.Fatalf("got %d inputs, want 3", len(inputs))
+	}
+	for _, input := range inputs {
+		if strings.Contains(input, `\r`) {
+			t.Errorf("jq input %s holds a carriage return", input)
+		}
+	}
+}
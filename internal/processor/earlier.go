@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+)
+
+// EarlierContent is a tea.Msg that conveys the content of the lines of the file
+// from FirstLine to LastLine read by a LoadEarlierOperation. If records were
+// requested then Records describes the record that produced each line of
+// Content.
+type EarlierContent struct {
+	Content   []string
+	Records   []Record
+	FirstLine int
+	LastLine  int
+	Err       error
+}
+
+// countLines returns the number of complete lines in the given file.
+func countLines(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	reader := bufio.NewReaderSize(file, 64*1024)
+	buf := make([]byte, 64*1024)
+	count := 0
+	for {
+		n, err := reader.Read(buf)
+		count += bytes.Count(buf[:n], []byte{'\n'})
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+}
+
+// lastLinesStart returns the first line of the file that the given Command reads
+// content from. If the Command has LastLines then that is the first of the
+// last LastLines lines of the file, unless FromLine is later.
+func lastLinesStart(cmd Command) (int, error) {
+	if cmd.LastLines < 1 {
+		return cmd.FromLine, nil
+	}
+	count, err := countLines(cmd.Path)
+	if err != nil {
+		return 0, err
+	}
+	return max(cmd.FromLine, count-cmd.LastLines+1), nil
+}
+
+// loadEarlier reads the content of the lines from FromLine to ToLine of the
+// given streamArgs once and sends it to the program as an EarlierContent
+// message. Nothing is sent if the run is canceled.
+func loadEarlier(args streamArgs) {
+	earlier := EarlierContent{FirstLine: max(args.cmd.FromLine, 1), LastLine: args.cmd.ToLine}
+	if args.cmd.Records && args.cmd.Offsets {
+		offsets, err := newOffsetTracker(args.cmd.Path, skippedLines(args.cmd))
+		if err != nil {
+			earlier.Err = err
+			args.program.Send(earlier)
+			return
+		}
+		args.offsets = offsets
+	}
+	lines, _, _, err := readContentLines(args, contentExecQuery(args.cmd, createJQContentQuery(args.cmd)))
+	if args.ctx.Err() != nil {
+		return
+	}
+	earlier.Err = err
+	if err == nil {
+		earlier.Content, earlier.Records = splitRecords(lines, skippedLines(args.cmd), args.offsets)
+	}
+	args.program.Send(earlier)
+}
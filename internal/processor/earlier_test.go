@@ -0,0 +1,96 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// writeTestFile writes the given content to a file in a temp directory and
+// returns its path.
+func writeTestFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCountLines(t *testing.T) {
+	tests := []struct {
+		content string
+		want    int
+	}{
+		{"", 0},
+		{"a\n", 1},
+		{"a\nb\nc\n", 3},
+		{"a\nb\nc", 2},
+		{"\n\n", 2},
+		{strings.Repeat("x\n", 100000), 100000},
+	}
+	for _, test := range tests {
+		got, err := countLines(writeTestFile(t, test.content))
+		if err != nil || got != test.want {
+			t.Errorf("countLines(%.20q) = %d, %v, want %d", test.content, got, err, test.want)
+		}
+	}
+	if _, err := countLines(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("countLines of a missing file succeeded")
+	}
+}
+
+func TestLastLinesStart(t *testing.T) {
+	path := writeTestFile(t, strings.Repeat("{}\n", 10))
+	tests := []struct {
+		name                string
+		fromLine, lastLines int
+		want                int
+	}{
+		{"all lines", 0, 0, 0},
+		{"from line only", 4, 0, 4},
+		{"last lines", 0, 3, 8},
+		{"from line before the tail", 2, 3, 8},
+		{"from line after the tail", 9, 3, 9},
+		{"more last lines than the file", 0, 20, 0},
+	}
+	for _, test := range tests {
+		got, err := lastLinesStart(Command{Path: path, FromLine: test.fromLine, LastLines: test.lastLines})
+		if err != nil || got != test.want {
+			t.Errorf("%s: got %d, %v, want %d", test.name, got, err, test.want)
+		}
+	}
+}
+
+func TestLoadEarlier(t *testing.T) {
+	if _, err := exec.LookPath("jq"); err != nil {
+		t.Skip("jq not found")
+	}
+	var lines []string
+	for _, n := range []string{"1", "2", "3", "4", "5"} {
+		lines = append(lines, `{"n":`+n+`}`)
+	}
+	path := writeTestFile(t, strings.Join(lines, "\n")+"\n")
+	program := &testSender{}
+	args := streamArgs{
+		ctx:       context.Background(),
+		program:   program,
+		cmd:       Command{Path: path, Format: ".n", Group: "*", FromLine: 2, ToLine: 4},
+		truncated: &atomic.Bool{},
+	}
+	loadEarlier(args)
+	if len(program.msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(program.msgs))
+	}
+	earlier, ok := program.msgs[0].(EarlierContent)
+	if !ok {
+		t.Fatalf("got %T, want EarlierContent", program.msgs[0])
+	}
+	if earlier.Err != nil || earlier.FirstLine != 2 || earlier.LastLine != 4 || strings.Join(earlier.Content, ",") != "2,3,4" {
+		t.Errorf("got %+v, want lines 2 to 4", earlier)
+	}
+}
@@ -0,0 +1,50 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// LookupEncoding returns the encoding with the given name, like "latin1" or
+// "windows-1252". An empty name or "utf-8" returns nil since input is UTF-8 by
+// default.
+func LookupEncoding(name string) (encoding.Encoding, error) {
+	if name == "" {
+		return nil, nil
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	canonical, _ := htmlindex.Name(enc)
+	if canonical == "utf-8" {
+		return nil, nil
+	}
+	if strings.HasPrefix(canonical, "utf-16") {
+		// Lines are split on newline bytes before they are decoded, which
+		// does not work for encodings where a newline is two bytes.
+		return nil, fmt.Errorf("unsupported encoding %q", name)
+	}
+	return enc, nil
+}
+
+// decodeFilter returns a lineFilter that decodes lines from the encoding with
+// the given name to UTF-8. It returns nil if no decoding is needed. Names are
+// validated at startup so an unknown name is treated as UTF-8.
+func decodeFilter(name string) lineFilter {
+	enc, err := LookupEncoding(name)
+	if err != nil || enc == nil {
+		return nil
+	}
+	decoder := enc.NewDecoder()
+	return func(line string) string {
+		decoded, err := decoder.String(line)
+		if err != nil {
+			return line
+		}
+		return decoded
+	}
+}
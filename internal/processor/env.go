@@ -0,0 +1,28 @@
+package processor
+
+import (
+	"context"
+	"os/exec"
+)
+
+// jqProgram returns the program of the jq command lines shown to the user for
+// the given Command. When the environment is hidden it is "env -i jq" so that
+// the command line runs jq the same way. Queries are shown as written, so
+// references to env and $ENV are never shown resolved.
+func jqProgram(cmd Command) string {
+	if cmd.NoEnv {
+		return "env -i jq"
+	}
+	return "jq"
+}
+
+// jqCommand returns the exec.Cmd that runs jq with the given arguments for the
+// given Command. If the Command hides the environment then jq is run with an
+// empty one, so that env and $ENV are empty objects.
+func jqCommand(ctx context.Context, cmd Command, args ...string) *exec.Cmd {
+	jqCmd := exec.CommandContext(ctx, "jq", args...)
+	if cmd.NoEnv {
+		jqCmd.Env = []string{}
+	}
+	return jqCmd
+}
@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// Error implements the error interface so that a ContentError can be reported
+// once the program exits.
+func (e ContentError) Error() string {
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e ContentError) Unwrap() error {
+	return e.Err
+}
+
+// Error implements the error interface so that a GroupsError can be reported
+// once the program exits.
+func (e GroupsError) Error() string {
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e GroupsError) Unwrap() error {
+	return e.Err
+}
+
+// QueryError is the error returned when jq rejects a query. Message holds the
+// stderr of jq.
+type QueryError struct {
+	Message string
+	Err     error
+	Jq      string
+}
+
+// Error implements the error interface.
+func (e QueryError) Error() string {
+	return e.Jq + ": " + e.Message
+}
+
+// Unwrap returns the underlying error.
+func (e QueryError) Unwrap() error {
+	return e.Err
+}
+
+// CheckQueries compiles the content and groups queries of the given Command
+// without reading the file. A QueryError is returned if jq rejects either
+// query. Errors starting jq, like exec.ErrNotFound, are returned as is.
+func CheckQueries(cmd Command) error {
+	cmd.Group = "*"
+	queries := []string{createJQContentQuery(cmd)}
+	if cmd.Selector != "" && !cmd.RawSelector {
+		queries = append(queries, createGroupsSelectorArg(cmd))
+	}
+	for _, query := range queries {
+		args := append([]string{"-n"}, groupArgs(cmd)...)
+		output, err := exec.Command("jq", append(args, "empty|("+query+")")...).CombinedOutput()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return QueryError{Message: strings.TrimSpace(string(output)), Err: err, Jq: jqCommandString(cmd, query)}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package processor
+
+import (
+	"os"
+	"strings"
+)
+
+// ReadFormatFile returns the jq expression in the given file as a format of
+// one line. The file may spread the expression over several lines and hold
+// comments, which start with a "#" outside of a string and run to the end of
+// the line. Comments are removed and the remaining lines are joined with
+// spaces.
+func ReadFormatFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(removeJQComment(line)); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " "), nil
+}
+
+// removeJQComment returns the given line of a jq program without its comment.
+func removeJQComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case !inString && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
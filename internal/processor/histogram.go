@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Histogram is a tea.Msg that conveys the distribution of the numeric values
+// produced by the format of the selected records. Counts holds the number of
+// values in each of the equal width buckets between Min and Max. If jq failed
+// then Err is set and Message holds the stderr of jq.
+type Histogram struct {
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Counts  []int   `json:"counts"`
+	Message string  `json:"-"`
+	Err     error   `json:"-"`
+	Jq      string  `json:"-"`
+}
+
+// createJQHistogramQuery returns a jq query string that buckets the numeric
+// values produced by the format of the records selected by the given Command.
+// The query reads raw lines with inputs, skips values that are not numbers or
+// that cause errors, and produces a single object with the min, max, and
+// bucket counts of the values.
+func createJQHistogramQuery(cmd Command) string {
+	buckets := max(cmd.Buckets, 1)
+	values := fmt.Sprintf("[inputs|(%s|%s|numbers)?]", createJQFilter(cmd), contentFormat(cmd))
+	bucket := fmt.Sprintf("if $max==$min then 0 else ([((.-$min)/($max-$min)*%d|floor),%d]|min) end", buckets, buckets-1)
+	return fmt.Sprintf(
+		"%s as $v|if ($v|length)==0 then {counts:[]} else "+
+			"($v|min) as $min|($v|max) as $max|"+
+			"{min:$min,max:$max,counts:(reduce ($v[]|%s) as $i ([range(%d)|0];.[$i]+=1))} end",
+		values, bucket, buckets)
+}
+
+// runHistogram buckets the values of the given Command over the current
+// contents of the file and sends the result to the program as a Histogram
+// message. Nothing is sent if the run is canceled.
+func runHistogram(args streamArgs) {
+	query := createJQHistogramQuery(args.cmd)
+	flags := []string{"-nRc"}
+	jqCmdString := jqProgram(args.cmd) + " " + strings.Join(append(flags, quotedGroupArgs(args.cmd)...), " ") + " " + shellQuote(query) + " " + shellQuote(args.cmd.Path)
+	jqCmd := jqCommand(args.ctx, args.cmd, append(append(flags, groupArgs(args.cmd)...), query, args.cmd.Path)...)
+	output, err := jqCmd.Output()
+	if args.ctx.Err() != nil {
+		return
+	}
+	histogram := Histogram{}
+	if err == nil {
+		err = json.Unmarshal(output, &histogram)
+	}
+	if err != nil {
+		histogram.Err = err
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			histogram.Message = strings.TrimSpace(string(exitErr.Stderr))
+		}
+	}
+	histogram.Jq = jqCmdString
+	args.program.Send(histogram)
+}
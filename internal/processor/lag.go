@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// lineQueueSize is how many produced lines may wait to be sent to the program.
+// While the queue is full the stream waits, as it would if the lines were sent
+// directly.
+const lineQueueSize = 10000
+
+// lineQueue decouples reading lines from jq from sending them to the program so
+// that the number of lines waiting to be rendered can be measured. Each line is
+// numbered as it is produced and is sent with the number of lines produced so
+// far.
+type lineQueue struct {
+	lines    chan ContentLine
+	produced atomic.Int64
+	done     chan struct{}
+}
+
+// newLineQueue returns a lineQueue that sends its lines to the given program
+// until the given context is done.
+func newLineQueue(ctx context.Context, program sender) *lineQueue {
+	q := &lineQueue{
+		lines: make(chan ContentLine, lineQueueSize),
+		done:  make(chan struct{}),
+	}
+	go func() {
+		defer close(q.done)
+		for line := range q.lines {
+			if ctx.Err() != nil {
+				// Drop lines of a canceled stream.
+				continue
+			}
+			line.Produced = int(q.produced.Load())
+			program.Send(line)
+		}
+	}()
+	return q
+}
+
+// add numbers the given line and queues it to be sent. The line is dropped if
+// the given context is done before it could be queued.
+func (q *lineQueue) add(ctx context.Context, line ContentLine) {
+	line.Seq = int(q.produced.Add(1))
+	select {
+	case q.lines <- line:
+	case <-ctx.Done():
+	}
+}
+
+// close stops the queue once the queued lines have been sent.
+func (q *lineQueue) close() {
+	close(q.lines)
+	<-q.done
+}
@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// truncatedMarker replaces the end of lines that are longer than the maximum
+// line length.
+const truncatedMarker = "… (truncated)"
+
+// maxUntruncatedLineBytes is the longest line that is read when lines are not
+// truncated. bufio.Scanner stops at lines longer than its buffer, which
+// defaults to 64 KiB.
+const maxUntruncatedLineBytes = 1 << 30
+
+// LinesTruncated is a tea.Msg that indicates that lines longer than MaxBytes
+// were truncated.
+type LinesTruncated struct {
+	MaxBytes int
+}
+
+// truncateLine returns the given line cut to at most maxBytes bytes, without
+// splitting a UTF-8 sequence, followed by the truncatedMarker. Lines that fit
+// and limits less than 1 return the line unchanged and false.
+func truncateLine(line []byte, maxBytes int) ([]byte, bool) {
+	if maxBytes < 1 || len(line) <= maxBytes {
+		return line, false
+	}
+	end := maxBytes
+	for end > 0 && !utf8.RuneStart(line[end]) {
+		end--
+	}
+	truncated := append([]byte{}, line[:end]...)
+	return append(truncated, truncatedMarker...), true
+}
+
+// truncateLines truncates each of the given lines with truncateLine. It
+// returns whether any line was truncated.
+func truncateLines(lines []string, maxBytes int) bool {
+	truncated := false
+	for i, line := range lines {
+		if newLine, ok := truncateLine([]byte(line), maxBytes); ok {
+			lines[i] = string(newLine)
+			truncated = true
+		}
+	}
+	return truncated
+}
+
+// newLineScanner returns a bufio.Scanner that splits the given reader into
+// lines ending with the given delimiter, like bufio.ScanLines does for
+// newlines. Lines longer than maxBytes are truncated with truncateLine, and the
+// rest of the line is discarded, rather than stopping the scanner. The given
+// function is called for each truncated line. Limits less than 1 read lines of
+// up to maxUntruncatedLineBytes unchanged.
+func newLineScanner(r io.Reader, delim byte, maxBytes int, onTruncate func()) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	if maxBytes < 1 {
+		scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxUntruncatedLineBytes)
+	} else {
+		scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxBytes+bufio.MaxScanTokenSize)
+	}
+	if maxBytes < 1 && delim == '\n' {
+		scanner.Split(bufio.ScanLines)
+		return scanner
+	}
+	skipping := false
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		i := bytes.IndexByte(data, delim)
+		if skipping {
+			if i < 0 {
+				return len(data), nil, nil
+			}
+			skipping = false
+			return i + 1, nil, nil
+		}
+		if i < 0 && (maxBytes < 1 || len(data) <= maxBytes) {
+			if atEOF && len(data) > 0 {
+				return len(data), data, nil
+			}
+			return 0, nil, nil
+		}
+		advance, line := i+1, data[:max(i, 0)]
+		if i < 0 {
+			advance, line = len(data), data
+			skipping = true
+		}
+		token, truncated := truncateLine(line, maxBytes)
+		if truncated {
+			onTruncate()
+		}
+		return advance, token, nil
+	})
+	return scanner
+}
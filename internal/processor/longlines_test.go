@@ -0,0 +1,47 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+)
+
+func scanAll(t *testing.T, input string, delim byte, maxBytes int) ([]string, int) {
+	t.Helper()
+	truncated := 0
+	scanner := newLineScanner(strings.NewReader(input), delim, maxBytes, func() { truncated++ })
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	return lines, truncated
+}
+
+func TestLineScannerOversizedLine(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+	input := "a\n" + long + "\nb\n"
+	for _, delim := range []byte{'\n', 0} {
+		in := strings.ReplaceAll(input, "\n", string(delim))
+		lines, truncated := scanAll(t, in, delim, 0)
+		if len(lines) != 3 || lines[0] != "a" || lines[1] != long || lines[2] != "b" {
+			t.Errorf("delim %q: got %d lines", delim, len(lines))
+		}
+		if truncated != 0 {
+			t.Errorf("delim %q: got %d truncated lines, want 0", delim, truncated)
+		}
+	}
+}
+
+func TestLineScannerTruncatesOversizedLine(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+	lines, truncated := scanAll(t, "a\n"+long+"\nb\n", '\n', 10)
+	want := []string{"a", strings.Repeat("x", 10) + truncatedMarker, "b"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+	if truncated != 1 {
+		t.Errorf("got %d truncated lines, want 1", truncated)
+	}
+}
@@ -0,0 +1,126 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// pathSampleLines is the number of lines at the start of the file that are
+// sampled when enumerating paths.
+const pathSampleLines = 100
+
+// SelectorSuggestion is a tea.Msg that suggests an existing path to use in
+// place of a selector that matched nothing.
+type SelectorSuggestion struct {
+	Selector   string
+	Suggestion string
+}
+
+// PathList is a tea.Msg that conveys the paths to the fields of the objects at
+// the start of the file, as sampled by samplePaths.
+type PathList struct {
+	Paths []string
+	Err   error
+}
+
+// samplePaths returns the sorted, distinct paths to the fields of the objects
+// in the first pathSampleLines lines of the given file. Paths are given in jq
+// syntax, like ".a.b". Array indexes are omitted.
+func samplePaths(ctx context.Context, path string) ([]string, error) {
+	headCmd := exec.CommandContext(ctx, "head", fmt.Sprintf("-%d", pathSampleLines), path)
+	jqCmd := exec.CommandContext(ctx, "jq", "-Rr", `try (fromjson|paths|map(select(type=="string"))|select(length>0)|"."+join(".")) catch empty`)
+	var out strings.Builder
+	jqCmd.Stdout = &out
+	stdout, err := headCmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	jqCmd.Stdin = stdout
+	if err := headCmd.Start(); err != nil {
+		return nil, err
+	}
+	if err := jqCmd.Run(); err != nil {
+		headCmd.Process.Kill()
+		headCmd.Wait()
+		return nil, err
+	}
+	headCmd.Wait()
+	paths := strings.Split(strings.TrimSpace(out.String()), "\n")
+	slices.Sort(paths)
+	return slices.Compact(paths), nil
+}
+
+// listPaths sends the sampled paths of the file of the given streamArgs to the
+// program as a PathList message. Nothing is sent if the run is canceled.
+func listPaths(args streamArgs) {
+	paths, err := samplePaths(args.ctx, args.cmd.Path)
+	if args.ctx.Err() != nil {
+		return
+	}
+	paths = slices.DeleteFunc(paths, func(path string) bool { return path == "" })
+	args.program.Send(PathList{Paths: paths, Err: err})
+}
+
+// suggestSelector sends a SelectorSuggestion to the program with the sampled
+// path that is most similar to the selector of the given streamArgs. Nothing
+// is sent if there are no paths, the closest is not similar, or the selector
+// is itself an existing path.
+func suggestSelector(args streamArgs) {
+	paths, err := samplePaths(args.ctx, args.cmd.Path)
+	if err != nil {
+		return
+	}
+	for i, path := range paths {
+		paths[i] = selectorSyntax(args.cmd, path)
+	}
+	suggestion := closestPath(args.cmd.Selector, paths)
+	if suggestion == "" || suggestion == args.cmd.Selector {
+		return
+	}
+	args.program.Send(SelectorSuggestion{
+		Selector:   args.cmd.Selector,
+		Suggestion: suggestion,
+	})
+}
+
+// closestPath returns the path with the smallest edit distance from the given
+// selector. An empty string is returned if the closest path differs in more
+// than half of the selector's characters.
+func closestPath(selector string, paths []string) string {
+	best := ""
+	bestDistance := len(selector)/2 + 1
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if distance := editDistance(selector, path); distance < bestDistance {
+			best = path
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between the given strings.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,125 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// pointerIdentifier matches the keys that can follow a '.' in a jq path.
+var pointerIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// pointerIndex matches the array indexes of a JSON pointer.
+var pointerIndex = regexp.MustCompile(`^(0|[1-9][0-9]*)$`)
+
+// PointerTokens returns the unescaped reference tokens of the given JSON
+// pointer (RFC 6901), like ["a", "b/c"] for "/a/b~1c". The empty pointer refers
+// to the whole document and has no tokens.
+func PointerTokens(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.New("a JSON pointer must start with /")
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, errors.New("~ must be escaped as ~0 in a JSON pointer")
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// PointerToPath returns the jq path equivalent to the given JSON pointer, like
+// ".a[0].b" for "/a/0/b". Tokens that are array indexes become jq indexes and
+// keys that are not identifiers are quoted, like .["b c"].
+func PointerToPath(pointer string) (string, error) {
+	tokens, err := PointerTokens(pointer)
+	if err != nil {
+		return "", err
+	}
+	if len(tokens) == 0 {
+		return ".", nil
+	}
+	var path strings.Builder
+	for _, token := range tokens {
+		if pointerIdentifier.MatchString(token) {
+			path.WriteString("." + token)
+			continue
+		}
+		if path.Len() == 0 {
+			path.WriteString(".")
+		}
+		if pointerIndex.MatchString(token) {
+			path.WriteString("[" + token + "]")
+			continue
+		}
+		quoted, _ := json.Marshal(token)
+		path.WriteString("[" + string(quoted) + "]")
+	}
+	return path.String(), nil
+}
+
+// PathToPointer returns the JSON pointer equivalent to the given jq path, like
+// "/a/0/b~1c" for ".a[0].b/c" or .a[0]["b/c"], so that it reverses
+// PointerToPath. Keys after a '.' end at the next '.' or '['.
+func PathToPointer(path string) string {
+	var pointer strings.Builder
+	for path != "" {
+		var key string
+		switch {
+		case path[0] == '.':
+			path = path[1:]
+			continue
+		case strings.HasPrefix(path, `["`):
+			end := stringEnd([]byte(path), 1)
+			if err := json.Unmarshal([]byte(path[1:min(end+1, len(path))]), &key); err != nil {
+				key = path[2:min(end, len(path))]
+			}
+			path = strings.TrimPrefix(path[min(end+1, len(path)):], "]")
+		case path[0] == '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				end = len(path)
+			}
+			key = path[1:end]
+			path = path[min(end+1, len(path)):]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			key, path = path[:end], path[end:]
+		}
+		pointer.WriteString("/" + strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1"))
+	}
+	return pointer.String()
+}
+
+// selectorPath returns the selector of the given Command as a jq path. JSON
+// pointers are translated when the Command is in pointer mode.
+func selectorPath(cmd Command) string {
+	if !cmd.Pointer || cmd.RawSelector || cmd.Selector == "" {
+		return cmd.Selector
+	}
+	path, err := PointerToPath(cmd.Selector)
+	if err != nil {
+		// Let jq report the selector.
+		return cmd.Selector
+	}
+	return path
+}
+
+// selectorSyntax returns the given dotted jq path as it is written in the
+// selector of the given Command.
+func selectorSyntax(cmd Command, path string) string {
+	if cmd.Pointer {
+		return PathToPointer(path)
+	}
+	return path
+}
@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPointerToPath(t *testing.T) {
+	tests := []struct {
+		pointer string
+		tokens  []string
+		path    string
+	}{
+		{"", nil, "."},
+		{"/a", []string{"a"}, ".a"},
+		{"/a/0", []string{"a", "0"}, ".a[0]"},
+		{"/a~1b", []string{"a/b"}, `.["a/b"]`},
+		{"/~01", []string{"~1"}, `.["~1"]`},
+		{"/a/b c/01", []string{"a", "b c", "01"}, `.a["b c"]["01"]`},
+		{"/0/a", []string{"0", "a"}, ".[0].a"},
+	}
+	for _, test := range tests {
+		tokens, err := PointerTokens(test.pointer)
+		if err != nil || !slices.Equal(tokens, test.tokens) {
+			t.Errorf("PointerTokens(%q) = %q, %v, want %q", test.pointer, tokens, err, test.tokens)
+		}
+		path, err := PointerToPath(test.pointer)
+		if err != nil || path != test.path {
+			t.Errorf("PointerToPath(%q) = %q, %v, want %q", test.pointer, path, err, test.path)
+		}
+		if pointer := PathToPointer(path); pointer != test.pointer {
+			t.Errorf("PathToPointer(%q) = %q, want %q", path, pointer, test.pointer)
+		}
+	}
+}
+
+func TestPointerTokensInvalid(t *testing.T) {
+	for _, pointer := range []string{"a/b", "/a~2", "/a~", "a"} {
+		if _, err := PointerTokens(pointer); err == nil {
+			t.Errorf("PointerTokens(%q) returned no error", pointer)
+		}
+		if _, err := PointerToPath(pointer); err == nil {
+			t.Errorf("PointerToPath(%q) returned no error", pointer)
+		}
+	}
+}
+
+func TestPathToPointerDotted(t *testing.T) {
+	tests := []struct {
+		path    string
+		pointer string
+	}{
+		{".", ""},
+		{".a.b", "/a/b"},
+		{".a-b.c", "/a-b/c"},
+		{".a/b.c~d", "/a~1b/c~0d"},
+	}
+	for _, test := range tests {
+		if pointer := PathToPointer(test.path); pointer != test.pointer {
+			t.Errorf("PathToPointer(%q) = %q, want %q", test.path, pointer, test.pointer)
+		}
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -22,6 +26,30 @@ const (
 	StartContentOperation = iota
 	// StartGroupsOperation tells the processor to begin streaming groups.
 	StartGroupsOperation
+	// RunScratchOperation tells the processor to run the scratch program once
+	// over the current contents of the file.
+	RunScratchOperation
+	// RunHistogramOperation tells the processor to bucket the numeric values
+	// produced by the format of the selected records once.
+	RunHistogramOperation
+	// ListPathsOperation tells the processor to list the paths to the fields
+	// of the objects at the start of the file.
+	ListPathsOperation
+	// RunContextOperation tells the processor to read the lines of the file
+	// around the given lines once.
+	RunContextOperation
+	// RunTimelineOperation tells the processor to bucket the times of the
+	// selected records once.
+	RunTimelineOperation
+	// LoadEarlierOperation tells the processor to read the content of the
+	// lines from FromLine to ToLine once.
+	LoadEarlierOperation
+	// StartTailOperation tells the processor to begin streaming content for
+	// the raw tail pane.
+	StartTailOperation
+	// StopTailOperation tells the processor to stop streaming content for the
+	// raw tail pane.
+	StopTailOperation
 	// StopOperation tells the processor to shut down all spawned children,
 	// contexts, and pipes.
 	StopOperation
@@ -34,8 +62,118 @@ type Command struct {
 	Format    string
 	Group     string
 	Path      string
+	// RawSelector indicates that Selector is a complete jq filter to be used
+	// verbatim rather than the path to a grouping field.
+	RawSelector bool
+	// Records indicates that each line of content should be sent along with
+	// the Record that produced it.
+	Records bool
+	// Offsets indicates that the Records sent should include the byte offset
+	// of the record in the file. It has no effect unless Records is set.
+	Offsets bool
+	// Relaxed indicates that input lines should be normalized from relaxed
+	// JSON (comments, trailing commas, unquoted keys) before reaching jq.
+	Relaxed bool
+	// SortKeys indicates that jq should output the keys of objects in sorted
+	// order.
+	SortKeys bool
+	// Indent is the number of spaces jq indents output objects with, or 0 for
+	// jq's default. It has no effect if Tab is set.
+	Indent int
+	// Tab indicates that jq should indent output objects with tabs.
+	Tab bool
+	// Lenient indicates that records that cause jq errors should be skipped
+	// rather than reported.
+	Lenient bool
+	// FromLine is the first line of the file to read. Earlier lines are
+	// skipped. Values less than 2 read the whole file.
+	FromLine int
+	// ToLine is the last line of the file read by LoadEarlierOperation.
+	// Values less than 1 read to the end of the file.
+	ToLine int
+	// LastLines is the number of lines at the end of the file that content is
+	// first read from, for files too long to read whole. Earlier lines are
+	// read by LoadEarlierOperation. Values less than 1 read from FromLine.
+	LastLines int
+	// Redact holds JSON paths whose values are replaced with "***" before
+	// records are selected, grouped, or formatted.
+	Redact []string
+	// Alert is a jq predicate. Records that match it are marked with Alert.
+	// It has no effect unless Records is set.
+	Alert string
+	// Mark is a jq predicate. Records that match it are marked with Marked.
+	// It has no effect unless Records is set.
+	Mark string
+	// Exists indicates that records are grouped by whether the path in
+	// Selector exists rather than by its value. The groups are "true" and
+	// "false".
+	Exists bool
+	// MaxLineBytes is the length beyond which lines of output are truncated.
+	// Values less than 1 do not truncate lines.
+	MaxLineBytes int
+	// ArrayGroups indicates that the selector identifies an array and that
+	// Group is one of its elements rather than its value.
+	ArrayGroups bool
+	// Program is the jq program run by RunScratchOperation.
+	Program string
+	// Encoding is the name of the encoding of the file. Lines are decoded to
+	// UTF-8 before they reach jq. An empty name means UTF-8.
+	Encoding string
+	// NulDelimited indicates that jq should end each line of content with a
+	// NUL rather than a newline, so that content lines may hold newlines.
+	NulDelimited bool
+	// OneLine indicates that each value produced by the format should be one
+	// line of content: objects are output compactly and the line breaks in
+	// strings are replaced by a visible \n.
+	OneLine bool
+	// HideNulls indicates that null values produced by the format are left
+	// out of the content rather than shown as "null". A field list is left
+	// out when all of its fields are null.
+	HideNulls bool
+	// RawFallback indicates that records for which the format produces
+	// nothing, only null or false, or an error are shown as their compact
+	// JSON rather than left out or shown as "null". A field list falls back
+	// when all of its fields are null.
+	RawFallback bool
+	// Buckets is the number of buckets used by RunHistogramOperation and
+	// RunTimelineOperation.
+	Buckets int
+	// TimeField is the dotted path to the time of each record used by
+	// RunTimelineOperation and by the time window of Since and Until.
+	TimeField string
+	// Since and Until bound the times of the records that are read. Records
+	// outside of them, or without a time, are skipped. A zero time does not
+	// bound the records. They have no effect unless TimeField is set.
+	Since time.Time
+	Until time.Time
+	// ThresholdField is the dotted path to a numeric field of each record.
+	// Records whose field is not a number of at least Threshold are skipped.
+	// An empty ThresholdField does not bound the records.
+	ThresholdField string
+	Threshold      float64
+	// ExcludedGroups are groups whose records are left out of the content,
+	// whichever group is selected. They are ignored with a raw selector.
+	ExcludedGroups []string
+	// Throttle is the maximum number of new lines of content sent per second
+	// while following the file. Values less than 1 do not limit the rate.
+	Throttle int
+	// Pointer indicates that Selector is a JSON pointer, like "/a/b", rather
+	// than a jq path. It has no effect if RawSelector is set.
+	Pointer bool
+	// NoEnv indicates that jq should be run with an empty environment so
+	// that queries cannot read environment variables through env or $ENV.
+	NoEnv bool
+	// Lines are the line numbers of the file that RunContextOperation reads
+	// around.
+	Lines []int
+	// Context is the number of lines before and after each of Lines that
+	// RunContextOperation reads.
+	Context int
 }
 
+// lineFilter transforms a line of input before it is passed to jq.
+type lineFilter func(string) string
+
 // CommandChannel is a tea.Msg that conveys the channel the processor will be
 // listening on for commands.
 type CommandChannel struct {
@@ -51,14 +189,23 @@ type ContentError struct {
 }
 
 // ContentLine is a tea.Msg that conveys a line of content read by the
-// processor.
+// processor. If records were requested then Record describes the record that
+// produced the line. Seq numbers the lines streamed after the initial content,
+// starting at 1, and Produced is the number of lines the processor had read
+// from jq when the line was sent, so Produced - Seq lines are waiting to be
+// rendered.
 type ContentLine struct {
-	Line string
+	Line     string
+	Record   Record
+	Seq      int
+	Produced int
 }
 
-// GroupsLine is a tea.Msg that conveys a group read by the processor.
+// GroupsLine is a tea.Msg that conveys a group read by the processor. Array
+// indicates that the group is an element of an array.
 type GroupsLine struct {
-	Line string
+	Line  string
+	Array bool
 }
 
 // GroupsError is a tea.Msg that conveys an error that occurred when looking
@@ -76,15 +223,38 @@ type JQCommand struct {
 }
 
 // ContentStart is a tea.Msg that indicates the processor is (re)starting a read
-// for content.
+// for content. If records were requested then InitialRecords describes the
+// record that produced each line of InitialContent. FirstLine is the line
+// number of the first line of the file that was read.
 type ContentStart struct {
 	InitialContent []string
+	InitialRecords []Record
+	FirstLine      int
 }
 
 // GroupsStart is a tea.Msg that indicates the processor is (re)starting a read
-// for groups.
+// for groups. Array indicates that the selector identifies an array, in which
+// case the groups are its elements.
 type GroupsStart struct {
 	InitialGroups []string
+	Array         bool
+}
+
+// arrayMarker precedes the groups emitted by jq that are elements of an array
+// rather than the value of the selector.
+const arrayMarker = "\x1f"
+
+// splitArrayGroups removes the arrayMarker from the given groups. It returns
+// whether any group had the marker.
+func splitArrayGroups(groups []string) bool {
+	array := false
+	for i, group := range groups {
+		if element, ok := strings.CutPrefix(group, arrayMarker); ok {
+			groups[i] = element
+			array = true
+		}
+	}
+	return array
 }
 
 // ContentStopped is a tea.Msg that indicates the processor has stopped. All child
@@ -105,8 +275,16 @@ func Run(program *tea.Program) {
 	program.Send(CommandChannel{CmdChan: cmdChan})
 	contentChan := make(chan streamArgs)
 	groupsChan := make(chan streamArgs)
+	tailChan := make(chan streamArgs)
 	var contentCancel func() = nil
+	var tailCancel func() = nil
 	var groupsCancel func() = nil
+	var scratchCancel func() = nil
+	var histogramCancel func() = nil
+	var pathsCancel func() = nil
+	var contextCancel func() = nil
+	var timelineCancel func() = nil
+	var earlierCancel func() = nil
 	go func() {
 		for {
 			streamArgs, ok := <-contentChan
@@ -114,7 +292,12 @@ func Run(program *tea.Program) {
 				program.Send(ContentStopped{})
 				return
 			}
-			streamContent(streamArgs)
+			runRecovered(streamContent, streamArgs, contentPanic)
+		}
+	}()
+	go func() {
+		for streamArgs := range tailChan {
+			runRecovered(streamContent, streamArgs, contentPanic)
 		}
 	}()
 	go func() {
@@ -124,7 +307,7 @@ func Run(program *tea.Program) {
 				program.Send(GroupsStopped{})
 				return
 			}
-			streamGroups(streamArgs)
+			runRecovered(streamGroups, streamArgs, groupsPanic)
 		}
 	}()
 	for {
@@ -137,10 +320,11 @@ func Run(program *tea.Program) {
 			var ctx context.Context
 			ctx, contentCancel = context.WithCancel(context.Background())
 			contentChan <- streamArgs{
-				ctx:     ctx,
-				cancel:  contentCancel,
-				program: program,
-				cmd:     cmd,
+				ctx:       ctx,
+				cancel:    contentCancel,
+				program:   program,
+				cmd:       cmd,
+				truncated: &atomic.Bool{},
 			}
 		case StartGroupsOperation:
 			if groupsCancel != nil {
@@ -149,20 +333,93 @@ func Run(program *tea.Program) {
 			var ctx context.Context
 			ctx, groupsCancel = context.WithCancel(context.Background())
 			groupsChan <- streamArgs{
-				ctx:     ctx,
-				cancel:  groupsCancel,
+				ctx:       ctx,
+				cancel:    groupsCancel,
+				program:   program,
+				cmd:       cmd,
+				truncated: &atomic.Bool{},
+			}
+		case StartTailOperation:
+			if tailCancel != nil {
+				tailCancel()
+			}
+			var ctx context.Context
+			ctx, tailCancel = context.WithCancel(context.Background())
+			tailChan <- streamArgs{
+				ctx:       ctx,
+				cancel:    tailCancel,
+				program:   tailSender{program: program},
+				cmd:       cmd,
+				truncated: &atomic.Bool{},
+			}
+		case StopTailOperation:
+			if tailCancel != nil {
+				tailCancel()
+				tailCancel = nil
+			}
+		case RunScratchOperation:
+			scratchCancel = startOnce(scratchCancel, runScratch, streamArgs{
+				program:   program,
+				cmd:       cmd,
+				truncated: &atomic.Bool{},
+			})
+		case RunHistogramOperation:
+			histogramCancel = startOnce(histogramCancel, runHistogram, streamArgs{
 				program: program,
 				cmd:     cmd,
-			}
+			})
+		case ListPathsOperation:
+			pathsCancel = startOnce(pathsCancel, listPaths, streamArgs{
+				program: program,
+				cmd:     cmd,
+			})
+		case RunContextOperation:
+			contextCancel = startOnce(contextCancel, readContext, streamArgs{
+				program: program,
+				cmd:     cmd,
+			})
+		case RunTimelineOperation:
+			timelineCancel = startOnce(timelineCancel, runTimeline, streamArgs{
+				program: program,
+				cmd:     cmd,
+			})
+		case LoadEarlierOperation:
+			earlierCancel = startOnce(earlierCancel, loadEarlier, streamArgs{
+				program:   program,
+				cmd:       cmd,
+				truncated: &atomic.Bool{},
+			})
 		case StopOperation:
 			if contentCancel != nil {
 				contentCancel()
 			}
+			if earlierCancel != nil {
+				earlierCancel()
+			}
+			if scratchCancel != nil {
+				scratchCancel()
+			}
+			if histogramCancel != nil {
+				histogramCancel()
+			}
+			if pathsCancel != nil {
+				pathsCancel()
+			}
+			if contextCancel != nil {
+				contextCancel()
+			}
+			if timelineCancel != nil {
+				timelineCancel()
+			}
+			if tailCancel != nil {
+				tailCancel()
+			}
 			if groupsCancel != nil {
 				groupsCancel()
 			}
 			close(contentChan)
 			close(groupsChan)
+			close(tailChan)
 			return
 		}
 	}
@@ -174,81 +431,150 @@ func Run(program *tea.Program) {
 type streamArgs struct {
 	ctx     context.Context
 	cancel  func()
-	program *tea.Program
+	program sender
 	cmd     Command
+	offsets *offsetTracker
+	// truncated is set once truncated lines have been reported.
+	truncated *atomic.Bool
+}
+
+// reportTruncated sends a LinesTruncated message to the program the first
+// time it is called for the stream.
+func (args streamArgs) reportTruncated() {
+	if args.truncated.CompareAndSwap(false, true) {
+		args.program.Send(LinesTruncated{MaxBytes: args.cmd.MaxLineBytes})
+	}
 }
 
 // streamContent parses the file and sends the parsed content to the program.
+// If the file does not exist yet then it is parsed once it is created. If the
+// file is truncated then it is parsed again from the beginning.
 func streamContent(args streamArgs) {
-	jqQuery := createJQContentQuery(args.cmd.Selector, args.cmd.Group, args.cmd.Format)
-	consumedLineCount, err := sendInitialContent(args, jqQuery)
+	if !waitForFile(args) {
+		return
+	}
+	for {
+		watch := watchTruncation(args.ctx, args.cmd.Path)
+		watchedArgs := args
+		watchedArgs.ctx = watch.ctx
+		streamContentOnce(watchedArgs)
+		if !watch.stop() {
+			return
+		}
+	}
+}
+
+// streamContentOnce parses the file and sends the parsed content to the
+// program. The jqQuery is the query reported to the program and the execQuery
+// is the query that is run, which differs when records are requested. If the
+// Command reads only the last lines of the file then the line they start at is
+// found first.
+func streamContentOnce(args streamArgs) {
+	jqQuery := createJQContentQuery(args.cmd)
+	fromLine, err := lastLinesStart(args.cmd)
 	if err != nil {
+		args.program.Send(ContentError{Message: "streamContent last lines", Err: err, Jq: jqCommandString(args.cmd, jqQuery)})
 		return
 	}
-	streamNewContent(args, jqQuery, consumedLineCount)
+	args.cmd.FromLine = fromLine
+	if args.cmd.Records && args.cmd.Offsets {
+		offsets, err := newOffsetTracker(args.cmd.Path, skippedLines(args.cmd))
+		if err != nil {
+			args.program.Send(ContentError{Message: "streamContent offsets", Err: err, Jq: jqCommandString(args.cmd, jqQuery)})
+			return
+		}
+		args.offsets = offsets
+	}
+	execQuery := contentExecQuery(args.cmd, jqQuery)
+	consumedLineCount, err := sendInitialContent(args, jqQuery, execQuery)
+	if err != nil {
+		return
+	}
+	streamNewContent(args, jqQuery, execQuery, consumedLineCount)
 }
 
 // sendInitialContent parses the current contents of the file and sends them as
 // a ContentStart message to the program. The number of lines read from the file
 // is returned.
-func sendInitialContent(args streamArgs, jqQuery string) (int, error) {
-	jqCmdString := "jq -Rr '" + jqQuery + "'"
+func sendInitialContent(args streamArgs, jqQuery, execQuery string) (int, error) {
+	jqCmdString := jqCommandString(args.cmd, jqQuery)
 	args.program.Send(JQCommand{
 		Jq: jqCmdString,
 	})
-	lineCount, err := countLines(args.cmd.Path)
-	if err != nil {
-		args.program.Send(ContentError{Message: "sendInitialContent count", Err: err, Jq: jqCmdString})
-		return 0, err
-	}
-	headCmd := exec.CommandContext(args.ctx, "head", fmt.Sprintf("-%d", lineCount), args.cmd.Path)
-	jqCmd := exec.CommandContext(args.ctx, "jq", "-Rr", jqQuery)
-	pipe, err := joinWithStderr(headCmd, jqCmd)
-	if err != nil {
-		args.program.Send(ContentError{Message: "sendInitialContent join", Err: err, Jq: jqCmdString})
-		return 0, err
-	}
-	err = start(headCmd, jqCmd)
+	initialLines, count, step, err := readContentLines(args, execQuery)
 	if err != nil {
 		if err != context.Canceled {
-			args.program.Send(ContentError{Message: "sendInitialContent start", Err: err, Jq: jqCmdString})
+			args.program.Send(ContentError{Message: "sendInitialContent " + step, Err: err, Jq: jqCmdString})
 		}
 		return 0, err
 	}
-	initialContentBytes, err := io.ReadAll(pipe)
-	if err != nil {
-		args.program.Send(ContentError{Message: "sendInitialContent io.ReadAll", Err: err, Jq: jqCmdString})
-		return 0, err
-	}
-	err = kill(headCmd, jqCmd)
-	if err != nil {
-		args.program.Send(ContentError{Message: "sendInitialContent kill", Err: err, Jq: jqCmdString})
-		return 0, err
-	}
 	// If we were cancled then don't send the content we gathered
 	select {
 	case <-args.ctx.Done():
 		return 0, nil
 	default:
 	}
-	initialContentBytes = bytes.TrimRight(initialContentBytes, "\n")
-	initialContent := strings.Split(string(initialContentBytes), "\n")
+	initialContent, initialRecords := splitRecords(initialLines, skippedLines(args.cmd), args.offsets)
 	args.program.Send(ContentStart{
 		InitialContent: initialContent,
+		InitialRecords: initialRecords,
+		FirstLine:      skippedLines(args.cmd) + 1,
 	})
-	return lineCount, nil
+	return count, nil
+}
+
+// readContentLines runs the given query once over the lines of the file that
+// the Command of the given streamArgs reads. It returns the lines output by jq,
+// truncated as the Command requests, and the number of lines read from the
+// file. If an error occurs then the step that failed is returned with it.
+func readContentLines(args streamArgs, execQuery string) ([]string, int, string, error) {
+	jqCmd := jqCommand(args.ctx, args.cmd, jqArgs(args.cmd, execQuery, contentFlags(args.cmd)...)...)
+	stdin, err := jqCmd.StdinPipe()
+	if err != nil {
+		return nil, 0, "stdin", err
+	}
+	pipe, err := joinWithStderr(nil, jqCmd)
+	if err != nil {
+		return nil, 0, "join", err
+	}
+	err = start(jqCmd)
+	if err != nil {
+		return nil, 0, "start", err
+	}
+	read := feedInitialLines(args.cmd, stdin, inputFilter(args.cmd, args.offsets))
+	contentBytes, err := io.ReadAll(pipe)
+	if err != nil {
+		return nil, 0, "io.ReadAll", err
+	}
+	lines := <-read
+	err = lines.err
+	if err == nil {
+		err = checkFromLine(args.cmd, lines.count)
+	}
+	if err != nil {
+		return nil, 0, "read", err
+	}
+	err = kill(jqCmd)
+	if err != nil {
+		return nil, 0, "kill", err
+	}
+	contentLines := splitOutput(args.cmd, contentBytes)
+	if truncateLines(contentLines, args.cmd.MaxLineBytes) {
+		args.reportTruncated()
+	}
+	return contentLines, lines.count, "", nil
 }
 
 // streamNewContent creates a command pipeline that connects tail -f and jq with
 // a query string assembled from the Selector, Format, and Group fields of the
 // given Command. The tail command starts at the given startLineNumber. Each
-// line emitted from jq is sent as a ContentLine message to the attached
-// tea.Program.
-func streamNewContent(args streamArgs, jqQuery string, startLineNumber int) {
-	jqCmdString := "jq -Rr '" + jqQuery + "'"
+// line emitted from jq is queued to be sent as a ContentLine message to the
+// attached tea.Program.
+func streamNewContent(args streamArgs, jqQuery, execQuery string, startLineNumber int) {
+	jqCmdString := jqCommandString(args.cmd, jqQuery)
 	tailCmd := exec.CommandContext(args.ctx, "tail", "-f", "-n", fmt.Sprintf("+%d", startLineNumber+1), args.cmd.Path)
-	jqCmd := exec.CommandContext(args.ctx, "jq", "-Rr", "--unbuffered", jqQuery)
-	stdoutPipe, err := joinWithStderr(tailCmd, jqCmd)
+	jqCmd := jqCommand(args.ctx, args.cmd, jqArgs(args.cmd, execQuery, append(contentFlags(args.cmd), "--unbuffered")...)...)
+	stdoutPipe, err := joinWithStderr(inputFilter(args.cmd, args.offsets), tailCmd, jqCmd)
 	if err != nil {
 		args.program.Send(ContentError{Message: "streamNewContent join", Err: err, Jq: jqCmdString})
 		return
@@ -260,8 +586,11 @@ func streamNewContent(args streamArgs, jqQuery string, startLineNumber int) {
 		}
 		return
 	}
-	scanner := bufio.NewScanner(stdoutPipe)
-	scanner.Split(bufio.ScanLines)
+	scanner := newLineScanner(stdoutPipe, outputDelimiter(args.cmd), args.cmd.MaxLineBytes, args.reportTruncated)
+	record := Record{Offset: -1}
+	pace := newPacer(args.cmd.Throttle)
+	queue := newLineQueue(args.ctx, args.program)
+	defer queue.close()
 	for scanner.Scan() {
 		select {
 		case <-args.ctx.Done():
@@ -271,17 +600,50 @@ func streamNewContent(args streamArgs, jqQuery string, startLineNumber int) {
 			}
 			return
 		default:
-			line := scanner.Text()
-			args.program.Send(ContentLine{
-				Line: line,
+			line := strings.TrimSuffix(scanner.Text(), "\r")
+			if args.cmd.Records && strings.HasPrefix(line, recordMarker) {
+				record = parseRecordLine(line, startLineNumber, args.offsets)
+				continue
+			}
+			if !pace.wait(args.ctx) {
+				// The canceled context is handled by the next iteration.
+				continue
+			}
+			queue.add(args.ctx, ContentLine{
+				Line:   line,
+				Record: record,
 			})
 		}
 	}
 }
 
 // streamGroups parses the file and sends the parsed content to the program.
+// Groups are not supported for raw selectors so an empty GroupsStart is sent
+// instead. If the file does not exist yet then it is parsed once it is
+// created. If the file is truncated then it is parsed again from the
+// beginning.
 func streamGroups(args streamArgs) {
-	jqQuery := createGroupsSelectorArg(args.cmd.Selector)
+	if args.cmd.RawSelector {
+		args.program.Send(GroupsStart{})
+		return
+	}
+	if !waitForFile(args) {
+		return
+	}
+	for {
+		watch := watchTruncation(args.ctx, args.cmd.Path)
+		watchedArgs := args
+		watchedArgs.ctx = watch.ctx
+		streamGroupsOnce(watchedArgs)
+		if !watch.stop() {
+			return
+		}
+	}
+}
+
+// streamGroupsOnce parses the file and sends the parsed groups to the program.
+func streamGroupsOnce(args streamArgs) {
+	jqQuery := createGroupsSelectorArg(args.cmd)
 	consumedLineCount, err := sendInitialGroups(args, jqQuery)
 	if err != nil {
 		return
@@ -293,32 +655,41 @@ func streamGroups(args streamArgs) {
 // a GroupsStart message to the program. The number of lines read from the file
 // is returned.
 func sendInitialGroups(args streamArgs, jqQuery string) (int, error) {
-	jqCmdString := "jq -Rr '" + jqQuery + "'"
-	lines, err := countLines(args.cmd.Path)
+	jqCmdString := jqCommandString(args.cmd, jqQuery)
+	jqCmd := jqCommand(args.ctx, args.cmd, jqArgs(args.cmd, jqQuery)...)
+	stdin, err := jqCmd.StdinPipe()
 	if err != nil {
-		args.program.Send(GroupsError{Message: "sendInitialGroups count", Err: err, Jq: jqCmdString})
+		args.program.Send(GroupsError{Message: "sendInitialGroups stdin", Err: err, Jq: jqCmdString})
 		return 0, err
 	}
-	headCmd := exec.CommandContext(args.ctx, "head", fmt.Sprintf("-%d", lines), args.cmd.Path)
-	jqCmd := exec.CommandContext(args.ctx, "jq", "-Rr", jqQuery, args.cmd.Path)
-	pipe, err := join(headCmd, jqCmd)
+	pipe, err := join(nil, jqCmd)
 	if err != nil {
 		args.program.Send(GroupsError{Message: "sendInitialGroups join", Err: err, Jq: jqCmdString})
 		return 0, err
 	}
-	err = start(headCmd, jqCmd)
+	err = start(jqCmd)
 	if err != nil {
 		if err != context.Canceled {
 			args.program.Send(GroupsError{Message: "sendInitialGroups start", Err: err, Jq: jqCmdString})
 		}
 		return 0, err
 	}
+	read := feedInitialLines(args.cmd, stdin, inputFilter(args.cmd, nil))
 	initialContentBytes, err := io.ReadAll(pipe)
 	if err != nil {
 		args.program.Send(GroupsError{Message: "sendInitialGroups io.ReadAll", Err: err, Jq: jqCmdString})
 		return 0, err
 	}
-	err = kill(headCmd, jqCmd)
+	lines := <-read
+	err = lines.err
+	if err == nil {
+		err = checkFromLine(args.cmd, lines.count)
+	}
+	if err != nil {
+		args.program.Send(GroupsError{Message: "sendInitialGroups read", Err: err, Jq: jqCmdString})
+		return 0, err
+	}
+	err = kill(jqCmd)
 	if err != nil {
 		args.program.Send(GroupsError{Message: "sendInitialContent kill", Err: err, Jq: jqCmdString})
 		return 0, err
@@ -331,13 +702,19 @@ func sendInitialGroups(args streamArgs, jqQuery string) (int, error) {
 	}
 	var initialContent []string
 	if len(initialContentBytes) != 0 && initialContentBytes[0] != '{' && initialContentBytes[0] != '[' {
-		initialContentBytes = bytes.TrimRight(initialContentBytes, "\n")
-		initialContent = strings.Split(string(initialContentBytes), "\n")
+		initialContent = splitLines(initialContentBytes)
+		if truncateLines(initialContent, args.cmd.MaxLineBytes) {
+			args.reportTruncated()
+		}
 	}
 	args.program.Send(GroupsStart{
 		InitialGroups: initialContent,
+		Array:         splitArrayGroups(initialContent),
 	})
-	return lines, nil
+	if len(initialContent) == 0 && args.cmd.Selector != "" {
+		suggestSelector(args)
+	}
+	return lines.count, nil
 }
 
 // streamNewGroups creates a command pipeline that connects tail -f and jq with a
@@ -345,10 +722,10 @@ func sendInitialGroups(args streamArgs, jqQuery string) (int, error) {
 // line emitted from jq is sent as a GroupsLine message to the attached
 // tea.Program.
 func streamNewGroups(args streamArgs, jqQuery string, startLineNumber int) {
-	jqCmdString := "jq -Rr '" + jqQuery + "'"
+	jqCmdString := jqCommandString(args.cmd, jqQuery)
 	tailCmd := exec.CommandContext(args.ctx, "tail", "-f", "-n", fmt.Sprintf("+%d", startLineNumber+1), args.cmd.Path)
-	jqCmd := exec.CommandContext(args.ctx, "jq", "-Rr", "--unbuffered", jqQuery)
-	stdoutPipe, err := join(tailCmd, jqCmd)
+	jqCmd := jqCommand(args.ctx, args.cmd, jqArgs(args.cmd, jqQuery, "--unbuffered")...)
+	stdoutPipe, err := join(inputFilter(args.cmd, nil), tailCmd, jqCmd)
 	if err != nil {
 		args.program.Send(GroupsError{Message: "streamNewGroups join", Err: err, Jq: jqCmdString})
 		return
@@ -360,8 +737,7 @@ func streamNewGroups(args streamArgs, jqQuery string, startLineNumber int) {
 		}
 		return
 	}
-	scanner := bufio.NewScanner(stdoutPipe)
-	scanner.Split(bufio.ScanLines)
+	scanner := newLineScanner(stdoutPipe, '\n', args.cmd.MaxLineBytes, args.reportTruncated)
 	for scanner.Scan() {
 		select {
 		case <-args.ctx.Done():
@@ -371,7 +747,7 @@ func streamNewGroups(args streamArgs, jqQuery string, startLineNumber int) {
 			}
 			return
 		default:
-			line := scanner.Text()
+			line := strings.TrimSuffix(scanner.Text(), "\r")
 			if line == "" || line[0] == '{' || line[0] == '[' {
 				args.cancel()
 				err = kill(tailCmd, jqCmd)
@@ -380,37 +756,195 @@ func streamNewGroups(args streamArgs, jqQuery string, startLineNumber int) {
 				}
 				return
 			}
+			element, array := strings.CutPrefix(line, arrayMarker)
 			args.program.Send(GroupsLine{
-				Line: line,
+				Line:  element,
+				Array: array,
 			})
 		}
 	}
 }
 
-// countLines returns the number of newline delimited lines in the given file.
-func countLines(path string) (int, error) {
+// lineOffset returns the byte offset of the start of the line following the
+// given number of lines in the given file.
+func lineOffset(path string, lines int) (int64, error) {
+	if lines < 1 {
+		return 0, nil
+	}
 	file, err := os.Open(path)
 	if err != nil {
 		return 0, err
 	}
 	defer file.Close()
-	buf := make([]byte, bufio.MaxScanTokenSize)
+	reader := bufio.NewReader(file)
+	var offset int64
+	for i := 0; i < lines; i++ {
+		line, err := reader.ReadSlice('\n')
+		offset += int64(len(line))
+		for err == bufio.ErrBufferFull {
+			line, err = reader.ReadSlice('\n')
+			offset += int64(len(line))
+		}
+		if err != nil {
+			return offset, err
+		}
+	}
+	return offset, nil
+}
+
+// skippedLines returns the number of lines at the start of the file that the
+// given Command skips.
+func skippedLines(cmd Command) int {
+	return max(cmd.FromLine-1, 0)
+}
+
+// checkFromLine returns an error if the given Command starts reading beyond
+// the end of a file with the given number of lines.
+func checkFromLine(cmd Command, lineCount int) error {
+	if skippedLines(cmd) > lineCount {
+		return fmt.Errorf("line %d is beyond the end of the file (%d lines)", cmd.FromLine, lineCount)
+	}
+	return nil
+}
+
+// initialRead is the result of feedInitialLines: the number of lines read from
+// the file, including skipped lines, or the error that stopped the read.
+type initialRead struct {
+	count int
+	err   error
+}
+
+// feedInitialLines writes the lines that are currently in the file of the
+// given Command to the given writer, passing each through the given
+// lineFilter, and then closes the writer. Lines that the Command skips are
+// counted but not written, and reading stops after the Command's ToLine. A
+// last line without a newline is still being written, so it is left for the
+// tail that follows. The lines are counted as they are read so that the file
+// is only read once, and the result is sent on the returned channel once the
+// read is done.
+func feedInitialLines(cmd Command, w io.WriteCloser, filter lineFilter) <-chan initialRead {
+	result := make(chan initialRead, 1)
+	go func() {
+		count, err := copyInitialLines(cmd, w, filter)
+		w.Close()
+		result <- initialRead{count: count, err: err}
+	}()
+	return result
+}
+
+// copyInitialLines does the work of feedInitialLines.
+func copyInitialLines(cmd Command, w io.Writer, filter lineFilter) (int, error) {
+	file, err := os.Open(cmd.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	skip := skippedLines(cmd)
+	reader := bufio.NewReader(file)
 	count := 0
+	// If jq stops reading, its output explains why, so the remaining lines
+	// are only counted.
+	writing := true
 	for {
-		n, err := file.Read(buf)
-		count += bytes.Count(buf[:n], []byte{'\n'})
+		if cmd.ToLine > 0 && count >= cmd.ToLine {
+			return count, nil
+		}
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return count, nil
+		}
 		if err != nil {
-			if err == io.EOF {
-				return count, nil
-			}
 			return count, err
 		}
+		count++
+		if count <= skip || !writing {
+			continue
+		}
+		if filter != nil {
+			line = filter(strings.TrimSuffix(line, "\n")) + "\n"
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			writing = false
+		}
 	}
 }
 
-// kill kills all the given exec.Cmds.
+// splitLines splits the given bytes into newline delimited lines. Trailing
+// newlines are ignored and a trailing carriage return is removed from each line
+// so that content with CRLF line endings displays cleanly.
+func splitLines(content []byte) []string {
+	content = bytes.TrimRight(content, "\r\n")
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// splitOutput splits the content output by jq for the given Command into
+// lines. NUL delimited output is split on NULs so that lines may hold
+// newlines. Empty output has no lines.
+func splitOutput(cmd Command, content []byte) []string {
+	if len(content) == 0 {
+		// jq output nothing, as it does for an empty file.
+		return nil
+	}
+	if !cmd.NulDelimited {
+		return splitLines(content)
+	}
+	content = bytes.TrimRight(content, "\x00")
+	if len(content) == 0 {
+		return []string{""}
+	}
+	return strings.Split(string(content), "\x00")
+}
+
+// outputDelimiter returns the byte that ends each line of content output by
+// jq for the given Command.
+func outputDelimiter(cmd Command) byte {
+	if cmd.NulDelimited {
+		return 0
+	}
+	return '\n'
+}
+
+// contentFlags returns the extra jq flags that are used when reading content
+// for the given Command. NUL delimited output joins the outputs of jq with no
+// newlines since the query ends each output with a NUL.
+func contentFlags(cmd Command) []string {
+	if cmd.NulDelimited {
+		return []string{"-j"}
+	}
+	return nil
+}
+
+// contentExecQuery returns the query run to produce the content of the given
+// Command, whose reported query is the given jqQuery. It differs when records
+// are requested or lines are NUL delimited.
+func contentExecQuery(cmd Command, jqQuery string) string {
+	execQuery := jqQuery
+	if cmd.Records {
+		execQuery = createJQRecordsQuery(cmd)
+	}
+	if cmd.NulDelimited {
+		execQuery = nulDelimitedQuery(execQuery)
+	}
+	return execQuery
+}
+
+// nulDelimitedQuery returns the given query with a NUL emitted after each of
+// its outputs.
+func nulDelimitedQuery(query string) string {
+	return fmt.Sprintf("(%s)|(.,\"\\u0000\")", query)
+}
+
+// kill kills all the given exec.Cmds. Commands that were never started are
+// skipped.
 func kill(cmds ...*exec.Cmd) error {
 	for _, cmd := range cmds {
+		if cmd.Process == nil {
+			continue
+		}
 		err := cmd.Process.Kill()
 		if err != nil {
 			return err
@@ -432,30 +966,32 @@ func start(cmds ...*exec.Cmd) error {
 }
 
 // join connects the stdout of each exec.Cmd in the given slice to the next
-// exec.Cmd in the slice. A reader connected to the stdout of the last exec.Cmd
-// in the list is returned. Stderr is ignored.
-func join(cmds ...*exec.Cmd) (io.Reader, error) {
+// exec.Cmd in the slice. The output of the first exec.Cmd is passed through the
+// given lineFilter, if any. A reader connected to the stdout of the last
+// exec.Cmd in the list is returned. Stderr is ignored.
+func join(filter lineFilter, cmds ...*exec.Cmd) (io.Reader, error) {
 	for i := 0; i < len(cmds)-1; i++ {
-		stdout, err := cmds[i].StdoutPipe()
+		err := connect(cmds[i], cmds[i+1], filter)
 		if err != nil {
 			return nil, err
 		}
-		cmds[i+1].Stdin = stdout
+		filter = nil
 	}
 	return cmds[len(cmds)-1].StdoutPipe()
 }
 
 // joinWithStderr connects the stdout of each exec.Cmd in the given slice to the
-// next exec.Cmd in the slice. The stderr of the last command is redirected to
-// stdou. A io.Reader connected to the stdout of the last exec.Cmd in the list
-// is returned.
-func joinWithStderr(cmds ...*exec.Cmd) (io.Reader, error) {
+// next exec.Cmd in the slice. The output of the first exec.Cmd is passed
+// through the given lineFilter, if any. The stderr of the last command is
+// redirected to stdou. A io.Reader connected to the stdout of the last
+// exec.Cmd in the list is returned.
+func joinWithStderr(filter lineFilter, cmds ...*exec.Cmd) (io.Reader, error) {
 	for i := 0; i < len(cmds)-1; i++ {
-		stdout, err := cmds[i].StdoutPipe()
+		err := connect(cmds[i], cmds[i+1], filter)
 		if err != nil {
 			return nil, err
 		}
-		cmds[i+1].Stdin = stdout
+		filter = nil
 	}
 
 	stdout, err := cmds[len(cmds)-1].StdoutPipe()
@@ -466,34 +1002,408 @@ func joinWithStderr(cmds ...*exec.Cmd) (io.Reader, error) {
 	return io.MultiReader(stdout), nil
 }
 
-// createJQContentQuery returns a jq query string for the given selector, group, and
-// format. The selector identifies the field that must exist in the JSON
-// objects, the group represents the value that the field must have, and the
-// format represents the format of the object to return. For example,
+// connect connects the stdout of the from exec.Cmd to the stdin of the to
+// exec.Cmd. If a lineFilter is given then each line is passed through it.
+func connect(from, to *exec.Cmd, filter lineFilter) error {
+	stdout, err := from.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if filter == nil {
+		to.Stdin = stdout
+		return nil
+	}
+	reader, writer := io.Pipe()
+	to.Stdin = reader
+	go func() {
+		writer.CloseWithError(filterLines(stdout, writer, filter))
+	}()
+	return nil
+}
+
+// filterLines copies newline delimited lines from the given reader to the
+// given writer, passing each line through the given lineFilter.
+func filterLines(r io.Reader, w io.Writer, filter lineFilter) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			newline := strings.HasSuffix(line, "\n")
+			line = filter(strings.TrimSuffix(line, "\n"))
+			if newline {
+				line += "\n"
+			}
+			if _, werr := io.WriteString(w, line); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// inputFilter returns the lineFilter that input lines must pass through before
+// reaching jq for the given Command, or nil if there is none. If an
+// offsetTracker is given then it records the offset of each line. Trailing
+// carriage returns are removed from the lines of files with CRLF line endings,
+// and from lines that are filtered anyway.
+func inputFilter(cmd Command, offsets *offsetTracker) lineFilter {
+	var filters []lineFilter
+	if offsets != nil {
+		filters = append(filters, offsets.track)
+	}
+	if decode := decodeFilter(cmd.Encoding); decode != nil {
+		filters = append(filters, decode)
+	}
+	if len(filters) > 0 || crlfLines(cmd.Path) {
+		filters = append(filters, trimCarriageReturn)
+	}
+	if cmd.Relaxed {
+		filters = append(filters, NormalizeRelaxedJSON)
+	}
+	switch len(filters) {
+	case 0:
+		return nil
+	case 1:
+		return filters[0]
+	}
+	return func(line string) string {
+		for _, filter := range filters {
+			line = filter(line)
+		}
+		return line
+	}
+}
+
+// crlfLines returns true if the first line of the file at the given path ends
+// with a carriage return before its newline, as lines of Windows-origin logs
+// do.
+func crlfLines(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	line, _ := bufio.NewReader(file).ReadString('\n')
+	return strings.HasSuffix(line, "\r\n")
+}
+
+// trimCarriageReturn is a lineFilter that removes a trailing carriage return.
+func trimCarriageReturn(line string) string {
+	return strings.TrimSuffix(line, "\r")
+}
+
+// jqFlags returns the flags passed to every jq invocation for the given
+// Command.
+func jqFlags(cmd Command) []string {
+	flags := []string{"-Rr"}
+	if cmd.SortKeys {
+		flags = append(flags, "-S")
+	}
+	return append(flags, indentFlags(cmd)...)
+}
+
+// indentFlags returns the jq flags that set the indentation of output objects
+// for the given Command, or nil for jq's default. One line output is compact.
+func indentFlags(cmd Command) []string {
+	if cmd.OneLine {
+		return []string{"-c"}
+	}
+	if cmd.Tab {
+		return []string{"--tab"}
+	}
+	if cmd.Indent > 0 {
+		return []string{"--indent", strconv.Itoa(cmd.Indent)}
+	}
+	return nil
+}
+
+// jqArgs returns the arguments for a jq invocation of the given query for the
+// given Command. Any extra flags are added after the common flags.
+func jqArgs(cmd Command, query string, extra ...string) []string {
+	args := append(jqFlags(cmd), groupArgs(cmd)...)
+	args = append(args, extra...)
+	return append(args, query)
+}
+
+// groupVariable is the jq variable that holds the selected group in the
+// format.
+const groupVariable = "$group"
+
+// groupArgs returns the jq arguments that set groupVariable to the group of
+// the given Command, or nil if its format does not use the variable.
+func groupArgs(cmd Command) []string {
+	if !strings.Contains(cmd.Format, groupVariable) {
+		return nil
+	}
+	return []string{"--arg", strings.TrimPrefix(groupVariable, "$"), cmd.Group}
+}
+
+// quotedGroupArgs returns the arguments of groupArgs with the group quoted for
+// a shell, as they are shown in jq commands.
+func quotedGroupArgs(cmd Command) []string {
+	args := groupArgs(cmd)
+	if args != nil {
+		args[2] = shellQuote(args[2])
+	}
+	return args
+}
+
+// shellQuote returns the given string quoted for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// jqCommandString returns the jq command line, as shown to the user, that runs
+// the given query for the given Command on its file.
+func jqCommandString(cmd Command, query string) string {
+	flags := append(jqFlags(cmd), quotedGroupArgs(cmd)...)
+	return jqProgram(cmd) + " " + strings.Join(flags, " ") + " " + shellQuote(query) + " " + shellQuote(cmd.Path)
+}
+
+// createJQContentQuery returns a jq query string for the selector, group, and
+// format of the given Command. The selector identifies the field that must
+// exist in the JSON objects, the group represents the value that the field must
+// have, and the format represents the format of the object to return. For
+// example,
 // seletor:= ".level"
 // group:="error"
 // format:=".timeStamp + \":\" + .message"
-func createJQContentQuery(selector, group, format string) string {
+// If the Command has a raw selector then the selector is used verbatim as the
+// filter and the group is ignored.
+func createJQContentQuery(cmd Command) string {
+	return lenientQuery(cmd, createJQFilter(cmd)+"|"+outputFormat(cmd))
+}
+
+// createJQRecordsQuery returns a jq query string like createJQContentQuery
+// except that the output for each record is preceded by a line holding the
+// recordMarker, the input line number, the alertMarker if the record matches
+// the alert predicate, and the compact JSON of the record.
+func createJQRecordsQuery(cmd Command) string {
+	return lenientQuery(cmd, fmt.Sprintf("%s|(\"\\u001e\"+(input_line_number|tostring)+\" \"+%s+%s+tojson), (%s)", createJQFilter(cmd), alertQuery(cmd), markQuery(cmd), outputFormat(cmd)))
+}
+
+// alertQuery returns a jq query string that produces the alertMarker if the
+// record matches the alert predicate of the given Command and an empty string
+// otherwise.
+func alertQuery(cmd Command) string {
+	return predicateQuery(cmd.Alert, alertMarker)
+}
+
+// markQuery returns a jq query string that produces the markMarker if the
+// record matches the mark predicate of the given Command and an empty string
+// otherwise.
+func markQuery(cmd Command) string {
+	return predicateQuery(cmd.Mark, markMarker)
+}
+
+// predicateQuery returns a jq query string that produces the given marker if
+// the record matches the given predicate and an empty string otherwise. Errors
+// in the predicate count as not matching.
+func predicateQuery(predicate, marker string) string {
+	if predicate == "" {
+		return `""`
+	}
+	return fmt.Sprintf(`(if (try (%s) catch false) then "%s" else "" end)`, predicate, marker)
+}
+
+// lenientQuery returns the given query wrapped so that errors are skipped if
+// the given Command is lenient. Otherwise the query is returned unchanged.
+func lenientQuery(cmd Command, query string) string {
+	if !cmd.Lenient {
+		return query
+	}
+	return fmt.Sprintf("try (%s) catch empty", query)
+}
+
+// createJQFilter returns the jq query string that selects the objects matching
+// the selector and group of the given Command, less those of its excluded
+// groups.
+func createJQFilter(cmd Command) string {
+	selector := selectorPath(cmd)
 	if selector == "" {
 		selector = "."
 	}
-	if format == "" {
-		format = "."
+	if cmd.RawSelector {
+		return fmt.Sprintf("%s|%s", parseRecordQuery(cmd), selector)
+	}
+	filter := groupFilter(cmd, selector)
+	for _, group := range cmd.ExcludedGroups {
+		filter += "|" + excludeGroupQuery(cmd, selector, group)
+	}
+	return filter
+}
+
+// groupFilter returns the jq query string that selects the objects where the
+// given selector matches the group of the given Command.
+func groupFilter(cmd Command, selector string) string {
+	group := cmd.Group
+	if cmd.Exists {
+		if group == "*" {
+			return parseRecordQuery(cmd)
+		}
+		return fmt.Sprintf("%s|select(%s==%s)", parseRecordQuery(cmd), existsQuery(selector), group)
 	}
 	if group == "*" {
-		return fmt.Sprintf(".|fromjson|select(%s)|%s", selector, format)
+		return fmt.Sprintf("%s|select(%s)", parseRecordQuery(cmd), selector)
 	}
-	return fmt.Sprintf(".|fromjson|select(%s==\"%s\")|%s", selector, group, format)
+	if cmd.ArrayGroups {
+		return fmt.Sprintf("%s|select(%s|arrays|index([%s]))", parseRecordQuery(cmd), selector, jqString(group))
+	}
+	return fmt.Sprintf("%s|select(%s==%s)", parseRecordQuery(cmd), selector, jqString(group))
+}
+
+// jqString returns the given string as a jq string literal, with any quotes,
+// backslashes and control characters escaped.
+func jqString(s string) string {
+	var quoted strings.Builder
+	encoder := json.NewEncoder(&quoted)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(quoted.String(), "\n")
+}
+
+// excludeGroupQuery returns the jq query string that drops the objects where
+// the given selector matches the given group, the negation of groupFilter.
+func excludeGroupQuery(cmd Command, selector, group string) string {
+	if cmd.Exists {
+		return fmt.Sprintf("select(%s!=%s)", existsQuery(selector), group)
+	}
+	if cmd.ArrayGroups {
+		return fmt.Sprintf("select((%s|arrays|index([%s]))//null|not)", selector, jqString(group))
+	}
+	return fmt.Sprintf("select(%s!=%s)", selector, jqString(group))
+}
+
+// existsQuery returns a jq query string that produces true if the given path
+// exists in the record and false otherwise. Unlike comparing the value with
+// null, this distinguishes a missing field from a field holding null.
+func existsQuery(selector string) string {
+	return fmt.Sprintf("(try (path(%s) as $p|getpath($p[:-1])|has($p[-1])) catch false)", selector)
 }
 
-// createGroupsSelectorArg returns a jq query string for the given selector. It
-// is expected that this selector identifies a field in a JSON object. Like
-// ".level" or ".object.field". The returned string, when passed to jq, will
-// produce a newline delimited list of strings that can be used to select
-// objects where the selector matches the value.
-func createGroupsSelectorArg(selector string) string {
+// parseRecordQuery returns the jq query string that parses each input line as
+// JSON and masks the redacted paths of the given Command. Redacted paths are
+// replaced with "***" only where they exist so that they are not added to
+// records without them. Records outside of the time window of the Command are
+// skipped.
+func parseRecordQuery(cmd Command) string {
+	query := ".|fromjson"
+	for _, path := range cmd.Redact {
+		query += fmt.Sprintf("|if %s? != null then %s=\"***\" else . end", path, path)
+	}
+	query += timeWindowQuery(cmd)
+	if cmd.ThresholdField != "" {
+		query += fmt.Sprintf("|select((%s|numbers) >= %s)", cmd.ThresholdField, strconv.FormatFloat(cmd.Threshold, 'g', -1, 64))
+	}
+	return query
+}
+
+// fieldListPattern matches the shorthand format of comma separated field
+// paths without leading dots, like "ts,level,msg" or "ts, properties.logger".
+var fieldListPattern = regexp.MustCompile(`^\s*[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*(\s*,\s*[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*)+\s*$`)
+
+// contentFormat returns the format of the given Command, defaulting to ".".
+// The shorthand format of comma separated field paths is expanded to the jq
+// expression that joins the values of those fields with spaces, so that
+// "ts,level,msg" becomes `[.ts,.level,.msg]|join(" ")`.
+func contentFormat(cmd Command) string {
+	if cmd.Format == "" {
+		return "."
+	}
+	if fields := FormatFields(cmd.Format); fields != nil {
+		return fmt.Sprintf(`[%s]|join(" ")`, strings.Join(fields, ","))
+	}
+	return cmd.Format
+}
+
+// FormatFields returns the jq paths of the fields of the given format if it is
+// the shorthand field list, like ".ts" and ".level" for "ts,level". It returns
+// nil for any other format.
+func FormatFields(format string) []string {
+	if !fieldListPattern.MatchString(format) {
+		return nil
+	}
+	fields := strings.Split(format, ",")
+	for i, field := range fields {
+		fields[i] = "." + strings.TrimSpace(field)
+	}
+	return fields
+}
+
+// nonNullQuery is a jq query that leaves out null values.
+const nonNullQuery = "select(. != null)"
+
+// nonNullFormat returns the given format, as returned by contentFormat, with
+// its null values left out if the given Command hides them. A field list is
+// left out when all of its fields are null, as it then joins to blanks.
+func nonNullFormat(cmd Command, format string) string {
+	switch {
+	case !cmd.HideNulls:
+		return format
+	case format == ".":
+		return nonNullQuery
+	case fieldListPattern.MatchString(cmd.Format):
+		fields := strings.TrimSuffix(format, `|join(" ")`)
+		return fields + `|select(any(. != null))|join(" ")`
+	}
+	return fmt.Sprintf("(%s)|%s", format, nonNullQuery)
+}
+
+// fallbackFormat returns the given format, as returned by nonNullFormat, with
+// the compact JSON of the record in place of what it fails to produce if the
+// given Command falls back to the raw record.
+func fallbackFormat(cmd Command, format string) string {
+	switch {
+	case !cmd.RawFallback || format == "." || format == nonNullQuery:
+		return format
+	case fieldListPattern.MatchString(cmd.Format) && !cmd.HideNulls:
+		fields := strings.TrimSuffix(format, `|join(" ")`)
+		format = fields + `|select(any(. != null))|join(" ")`
+	}
+	return fmt.Sprintf("(%s)? // tojson", format)
+}
+
+// outputFormat returns the format of the given Command as used for the lines
+// of content. A format given as a jq expression prints strings as they are and
+// any other value, like a number, boolean, null, object, or array, as its
+// compact JSON, so that each value it produces is one line of content. Without
+// a format, records are printed as jq prints them. For one line output, the
+// line breaks in strings are replaced by a visible \n, so that a string never
+// spans several lines. Null values are left out first if the Command hides
+// them, and the record is shown in their place if the Command falls back to
+// it.
+func outputFormat(cmd Command) string {
+	format := fallbackFormat(cmd, nonNullFormat(cmd, contentFormat(cmd)))
+	switch {
+	case format == "." || format == nonNullQuery:
+		return format
+	case cmd.OneLine:
+		return fmt.Sprintf(`(%s)|if type=="string" then gsub("\r?\n";"\\n") else tojson end`, format)
+	case fieldListPattern.MatchString(cmd.Format):
+		return format
+	}
+	return fmt.Sprintf(`(%s)|if type=="string" then . else tojson end`, format)
+}
+
+// createGroupsSelectorArg returns a jq query string for the selector of the
+// given Command. It is expected that this selector identifies a field in a JSON
+// object. Like ".level" or ".object.field". The returned string, when passed to
+// jq, will produce a newline delimited list of strings that can be used to
+// select objects where the selector matches the value. If the selector
+// identifies an array then each element is a group, preceded by the
+// arrayMarker.
+func createGroupsSelectorArg(cmd Command) string {
+	selector := selectorPath(cmd)
 	if selector == "" {
-		return ".|fromjson"
+		return lenientQuery(cmd, parseRecordQuery(cmd))
+	}
+	if cmd.Exists {
+		return lenientQuery(cmd, fmt.Sprintf("%s|%s", parseRecordQuery(cmd), existsQuery(selector)))
 	}
-	return fmt.Sprintf(".|fromjson|select(%s)|%s", selector, selector)
+	return lenientQuery(cmd, fmt.Sprintf("%s|select(%s)|%s|if type==\"array\" then .[]|\"\\u001f\"+tostring else . end", parseRecordQuery(cmd), selector, selector))
 }
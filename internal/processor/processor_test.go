@@ -0,0 +1,156 @@
+package processor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runJQ runs the given query on the given input lines with the flags jlv uses
+// and returns the output lines.
+func runJQ(t *testing.T, cmd Command, query string, lines ...string) []string {
+	t.Helper()
+	if _, err := exec.LookPath("jq"); err != nil {
+		t.Skip("jq not found")
+	}
+	jqCmd := exec.Command("jq", jqArgs(cmd, query)...)
+	jqCmd.Stdin = strings.NewReader(strings.Join(lines, "\n") + "\n")
+	output, err := jqCmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("jq %q: %v: %s", query, err, output)
+	}
+	return splitLines(output)
+}
+
+func TestJQCommandStringQuotesPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "it's.json")
+	if err := os.WriteFile(path, []byte(`{"a":1}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	jq := jqCommandString(Command{Path: path}, "fromjson|.a")
+	if !strings.HasSuffix(jq, " "+shellQuote(path)) {
+		t.Fatalf("got %q, want it to end with the quoted path", jq)
+	}
+	if _, err := exec.LookPath("jq"); err != nil {
+		t.Skip("jq not found")
+	}
+	output, err := exec.Command("sh", "-c", jq).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %v: %s", jq, err, output)
+	}
+	if got := strings.TrimSpace(string(output)); got != "1" {
+		t.Errorf("%s: got %q, want %q", jq, got, "1")
+	}
+}
+
+func TestGroupFilterArrayGroups(t *testing.T) {
+	records := []string{
+		`{"tags":["a","b"],"m":1}`,
+		`{"tags":["b"],"m":2}`,
+		`{"tags":"a","m":3}`,
+		`{"tags":["say \"hi\"","back\\slash"],"m":4}`,
+		`{"m":5}`,
+	}
+	tests := []struct {
+		group string
+		want  []string
+	}{
+		{"a", []string{"1"}},
+		{"b", []string{"1", "2"}},
+		{`say "hi"`, []string{"4"}},
+		{`back\slash`, []string{"4"}},
+		{"c", nil},
+	}
+	for _, test := range tests {
+		cmd := Command{Selector: ".tags", Format: ".m", Group: test.group, ArrayGroups: true}
+		got := runJQ(t, cmd, createJQContentQuery(cmd), records...)
+		if strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("group %q: got %q, want %q", test.group, got, test.want)
+		}
+	}
+}
+
+func TestGroupFilterEscapesGroup(t *testing.T) {
+	records := []string{`{"l":"a\"b","m":1}`, `{"l":"a","m":2}`}
+	cmd := Command{Selector: ".l", Format: ".m", Group: `a"b`}
+	if got := runJQ(t, cmd, createJQContentQuery(cmd), records...); strings.Join(got, ",") != "1" {
+		t.Errorf("got %q, want [1]", got)
+	}
+}
+
+func TestExcludedGroupsEscapeGroup(t *testing.T) {
+	records := []string{`{"l":"a\"b","m":1}`, `{"l":"c\\d","m":2}`, `{"l":"e","m":3}`}
+	cmd := Command{Selector: ".l", Format: ".m", Group: "*", ExcludedGroups: []string{`a"b`, `c\d`}}
+	if got := runJQ(t, cmd, createJQContentQuery(cmd), records...); strings.Join(got, ",") != "3" {
+		t.Errorf("got %q, want [3]", got)
+	}
+	records = []string{`{"t":["a\"b"],"m":1}`, `{"t":["e"],"m":2}`}
+	cmd = Command{Selector: ".t", Format: ".m", Group: "*", ArrayGroups: true, ExcludedGroups: []string{`a"b`}}
+	if got := runJQ(t, cmd, createJQContentQuery(cmd), records...); strings.Join(got, ",") != "2" {
+		t.Errorf("array groups: got %q, want [2]", got)
+	}
+}
+
+func TestSplitOutputEmpty(t *testing.T) {
+	for _, cmd := range []Command{{}, {NulDelimited: true}} {
+		if lines := splitOutput(cmd, nil); len(lines) != 0 {
+			t.Errorf("NulDelimited %v: got %q, want no lines", cmd.NulDelimited, lines)
+		}
+	}
+}
+
+func TestOutputFormatNonStringValues(t *testing.T) {
+	record := `{"s":"text","n":1.5,"b":true,"o":{"k":[1,2]},"a":["x","y"],"z":null}`
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{".s", []string{"text"}},
+		{".n", []string{"1.5"}},
+		{".b", []string{"true"}},
+		{".o", []string{`{"k":[1,2]}`}},
+		{".a", []string{`["x","y"]`}},
+		{".z", []string{"null"}},
+		{".n, .b", []string{"1.5", "true"}},
+	}
+	for _, test := range tests {
+		cmd := Command{Format: test.format, Group: "*"}
+		got := runJQ(t, cmd, createJQContentQuery(cmd), record)
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("format %q: got %q, want %q", test.format, got, test.want)
+		}
+	}
+}
+
+func TestOneLineMultilineStrings(t *testing.T) {
+	records := []string{
+		`{"msg":"first\nsecond","n":1}`,
+		`{"msg":"crlf\r\nline","n":2}`,
+		`{"msg":"plain","n":3}`,
+	}
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{".msg", []string{`first\nsecond`, `crlf\nline`, "plain"}},
+		{"", []string{
+			`{"msg":"first\nsecond","n":1}`,
+			`{"msg":"crlf\r\nline","n":2}`,
+			`{"msg":"plain","n":3}`,
+		}},
+		{"{msg}", []string{`{"msg":"first\nsecond"}`, `{"msg":"crlf\r\nline"}`, `{"msg":"plain"}`}},
+	}
+	for _, test := range tests {
+		cmd := Command{Format: test.format, Group: "*", OneLine: true}
+		got := runJQ(t, cmd, createJQContentQuery(cmd), records...)
+		if strings.Join(got, "|") != strings.Join(test.want, "|") {
+			t.Errorf("format %q: got %q, want one line per record %q", test.format, got, test.want)
+		}
+	}
+	cmd := Command{Format: ".msg", Group: "*"}
+	if got := runJQ(t, cmd, createJQContentQuery(cmd), records[0]); len(got) != 2 {
+		t.Errorf("without OneLine got %q, want the string on two lines", got)
+	}
+}
@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// recordMarker prefixes the lines emitted by jq that describe a record rather
+// than formatted content. The marker is followed by the input line number of
+// the record, a space, the alertMarker if the record matches the alert
+// predicate, the markMarker if it matches the mark predicate, and the compact
+// JSON of the record.
+const recordMarker = "\x1e"
+
+// alertMarker precedes the JSON of a record line if the record matches the
+// alert predicate. It cannot be the start of valid JSON.
+const alertMarker = "!"
+
+// markMarker precedes the JSON of a record line, after any alertMarker, if the
+// record matches the mark predicate. It cannot be the start of valid JSON.
+const markMarker = "*"
+
+// Record describes the record that produced a line of content.
+type Record struct {
+	// JSON is the compact JSON of the record.
+	JSON string
+	// Line is the line number of the record in the file, or 0 if unknown.
+	Line int
+	// Offset is the byte offset of the record in the file, or -1 if unknown.
+	Offset int64
+	// Alert indicates that the record matches the alert predicate.
+	Alert bool
+	// Marked indicates that the record matches the mark predicate.
+	Marked bool
+}
+
+// parseRecordLine returns the Record described by the given record line. The
+// input line numbers reported by jq are relative to the first line read, so
+// lineBase is the number of lines of the file that preceded it. If an
+// offsetTracker is given then it is used to find the offset of the record.
+func parseRecordLine(line string, lineBase int, offsets *offsetTracker) Record {
+	record := Record{Offset: -1}
+	number, json, _ := strings.Cut(line[len(recordMarker):], " ")
+	json, record.Alert = strings.CutPrefix(json, alertMarker)
+	json, record.Marked = strings.CutPrefix(json, markMarker)
+	record.JSON = json
+	if n, err := strconv.Atoi(number); err == nil {
+		record.Line = lineBase + n
+		if offsets != nil {
+			record.Offset = offsets.offset(record.Line)
+		}
+	}
+	return record
+}
+
+// splitRecords separates the record lines emitted by a records query from the
+// content lines. It returns the content lines and a slice of the same length
+// holding the Record that produced each content line. If there are no record
+// lines then the returned Records are all empty.
+func splitRecords(lines []string, lineBase int, offsets *offsetTracker) ([]string, []Record) {
+	content := make([]string, 0, len(lines))
+	records := make([]Record, 0, len(lines))
+	record := Record{Offset: -1}
+	for _, line := range lines {
+		if strings.HasPrefix(line, recordMarker) {
+			record = parseRecordLine(line, lineBase, offsets)
+			continue
+		}
+		content = append(content, line)
+		records = append(records, record)
+	}
+	return content, records
+}
+
+// offsetTracker records the byte offset of each line of the file as it is
+// passed to jq. The initial read and the tail that follows it pass through the
+// same tracker so that line numbers continue across them.
+type offsetTracker struct {
+	mu      sync.Mutex
+	first   int
+	offsets []int64
+	next    int64
+}
+
+// newOffsetTracker returns an offsetTracker for the given file whose first
+// tracked line follows the given number of skipped lines.
+func newOffsetTracker(path string, skip int) (*offsetTracker, error) {
+	next, err := lineOffset(path, skip)
+	if err != nil {
+		return nil, err
+	}
+	return &offsetTracker{first: skip + 1, next: next}, nil
+}
+
+// track is a lineFilter that records the offset of the given line and returns
+// it unchanged. Every line is assumed to end in a single newline.
+func (t *offsetTracker) track(line string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.offsets = append(t.offsets, t.next)
+	t.next += int64(len(line)) + 1
+	return line
+}
+
+// offset returns the byte offset of the given 1-based line number, or -1 if
+// the line has not been tracked.
+func (t *offsetTracker) offset(line int) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	idx := line - t.first
+	if idx < 0 || idx >= len(t.offsets) {
+		return -1
+	}
+	return t.offsets[idx]
+}
@@ -0,0 +1,31 @@
+package processor
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runRecovered runs the given function with the given streamArgs. If it
+// panics then the panic is recovered and reported to the program with the
+// message made by the given function, so that the error is shown and the
+// processor keeps serving commands rather than the application crashing. The
+// stack is left out, as the message is shown in the output window.
+func runRecovered(run func(streamArgs), args streamArgs, report func(error) tea.Msg) {
+	defer func() {
+		if r := recover(); r != nil {
+			args.program.Send(report(fmt.Errorf("panic: %v", r)))
+		}
+	}()
+	run(args)
+}
+
+// contentPanic returns the ContentError that reports the given panic.
+func contentPanic(err error) tea.Msg {
+	return ContentError{Message: "reading content", Err: err}
+}
+
+// groupsPanic returns the GroupsError that reports the given panic.
+func groupsPanic(err error) tea.Msg {
+	return GroupsError{Message: "reading groups", Err: err}
+}
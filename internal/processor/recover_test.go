@@ -0,0 +1,95 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// testSender records the messages sent to it.
+type testSender struct {
+	msgs []tea.Msg
+}
+
+// Send implements sender.
+func (s *testSender) Send(msg tea.Msg) {
+	s.msgs = append(s.msgs, msg)
+}
+
+func TestKillSkipsCommandsThatFailedToStart(t *testing.T) {
+	missing := exec.Command(filepath.Join(t.TempDir(), "missing"))
+	if err := start(missing); err == nil {
+		t.Fatal("start of a missing program succeeded")
+	}
+	if err := kill(missing); err != nil {
+		t.Errorf("kill: %v", err)
+	}
+}
+
+func TestStreamContentReportsStartFailure(t *testing.T) {
+	// Neither tail nor jq can be found, so the first command fails to
+	// start.
+	t.Setenv("PATH", t.TempDir())
+	path := filepath.Join(t.TempDir(), "test.json")
+	if err := os.WriteFile(path, []byte(`{"a":1}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	program := &testSender{}
+	args := streamArgs{
+		ctx:       context.Background(),
+		program:   program,
+		cmd:       Command{Path: path, Group: "*"},
+		truncated: &atomic.Bool{},
+	}
+	done := make(chan struct{})
+	go func() {
+		runRecovered(streamContent, args, contentPanic)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("streamContent did not return after the start failure")
+	}
+	var contentErr ContentError
+	for _, msg := range program.msgs {
+		if err, ok := msg.(ContentError); ok {
+			contentErr = err
+		}
+	}
+	if !errors.Is(contentErr.Err, exec.ErrNotFound) {
+		t.Fatalf("got messages %+v, want a ContentError for the missing program", program.msgs)
+	}
+}
+
+func TestRunRecoveredReportsPanic(t *testing.T) {
+	program := &testSender{}
+	runRecovered(func(streamArgs) { panic("boom") }, streamArgs{program: program}, contentPanic)
+	if len(program.msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(program.msgs))
+	}
+	msg, ok := program.msgs[0].(ContentError)
+	if !ok {
+		t.Fatalf("got %T, want ContentError", program.msgs[0])
+	}
+	if msg.Err.Error() != "panic: boom" || strings.Contains(msg.Message, "goroutine") {
+		t.Errorf("got %q and %q, want the panic without a stack", msg.Err, msg.Message)
+	}
+}
+
+func TestRunRecoveredSendsNothingWithoutPanic(t *testing.T) {
+	program := &testSender{}
+	ran := false
+	runRecovered(func(streamArgs) { ran = true }, streamArgs{program: program}, groupsPanic)
+	if !ran || len(program.msgs) != 0 {
+		t.Errorf("got ran %v and %d messages, want true and 0", ran, len(program.msgs))
+	}
+}
@@ -0,0 +1,302 @@
+package processor
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// relaxedInfinity is the number written for Infinity, the largest double, as
+// jq prints infinite values.
+const relaxedInfinity = "1.7976931348623157e+308"
+
+// NormalizeRelaxedJSON converts relaxed JSON (JSON5) into standard JSON that jq
+// can parse. It accepts "//" and "/* */" comments, trailing commas in objects
+// and arrays, unquoted object keys, single quoted strings and the JSON5
+// escapes in strings, hexadecimal numbers, numbers with a leading "+" or a
+// leading or trailing decimal point, and Infinity and NaN. Infinity is written
+// as the largest double and NaN as null, as jq prints them. The text may span
+// several lines. Text that is already standard JSON is returned unchanged.
+func NormalizeRelaxedJSON(text string) string {
+	return removeTrailingCommas(normalizeTokens(text))
+}
+
+// SplitRelaxedValue returns the first top level relaxed JSON object or array of
+// the given text, with any comments before it, and the text after it. It
+// returns false if the text does not hold a complete value yet. Brackets in
+// strings and comments are ignored.
+func SplitRelaxedValue(text []byte) ([]byte, []byte, bool) {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '"' || c == '\'':
+			end := stringEnd(text, i)
+			if end == len(text) {
+				return nil, text, false
+			}
+			i = end
+		case c == '/' && i+1 < len(text) && text[i+1] == '/':
+			end := bytes.IndexByte(text[i:], '\n')
+			if end < 0 {
+				return nil, text, false
+			}
+			i += end
+		case c == '/' && i+1 < len(text) && text[i+1] == '*':
+			end := bytes.Index(text[i+2:], []byte("*/"))
+			if end < 0 {
+				return nil, text, false
+			}
+			i += end + 3
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+			if depth <= 0 {
+				return text[:i+1], text[i+1:], true
+			}
+		}
+	}
+	return nil, text, false
+}
+
+// normalizeTokens removes comments, converts single quoted strings to double
+// quoted strings, quotes unquoted object keys, and converts numbers to the
+// standard form.
+func normalizeTokens(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '"' || c == '\'':
+			i = copyString(&b, text, i)
+		case c == '/' && i+1 < len(text) && text[i+1] == '/':
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				return b.String()
+			}
+			// Keep the line break that ends the comment.
+			i += end - 1
+		case c == '/' && i+1 < len(text) && text[i+1] == '*':
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 3
+		case isNumberStart(text, i):
+			i = copyNumber(&b, text, i)
+		case isIdentifierStart(c):
+			start := i
+			for i+1 < len(text) && isIdentifierPart(text[i+1]) {
+				i++
+			}
+			identifier := text[start : i+1]
+			next := strings.TrimLeft(text[i+1:], " \t\r\n")
+			switch {
+			case strings.HasPrefix(next, ":"):
+				b.WriteString(`"` + identifier + `"`)
+			case identifier == "Infinity":
+				b.WriteString(relaxedInfinity)
+			case identifier == "NaN":
+				b.WriteString("null")
+			default:
+				b.WriteString(identifier)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// stringEnd returns the index of the quote that closes the string starting at
+// the given index of text, or the length of text if the string does not end.
+func stringEnd(text []byte, start int) int {
+	quote := text[start]
+	for i := start + 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		}
+	}
+	return len(text)
+}
+
+// copyString writes the string starting at the given index of text to b as a
+// double quoted string and returns the index of the closing quote. Escapes
+// that JSON lacks are converted, and escaped line breaks, which continue the
+// string on the next line, are dropped.
+func copyString(b *strings.Builder, text string, start int) int {
+	quote := text[start]
+	b.WriteByte('"')
+	for i := start + 1; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text):
+			i++
+			switch e := text[i]; {
+			case strings.IndexByte(`"\/bfnrtu`, e) >= 0:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			case e == 'x' && i+2 < len(text):
+				b.WriteString(`\u00` + text[i+1:i+3])
+				i += 2
+			case e == 'v':
+				b.WriteString(`\u000b`)
+			case e == '0':
+				b.WriteString(`\u0000`)
+			case e == '\r' && i+1 < len(text) && text[i+1] == '\n':
+				i++
+			case e == '\n' || e == '\r':
+			default:
+				// Any other escaped character, like a single quote,
+				// stands for itself.
+				b.WriteByte(e)
+			}
+		case c == quote:
+			b.WriteByte('"')
+			return i
+		case c == '"':
+			b.WriteString(`\"`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return len(text)
+}
+
+// isNumberStart returns true if a number starts at the given index of text:
+// a digit, or a sign or decimal point before a digit, or a sign before
+// Infinity or NaN.
+func isNumberStart(text string, i int) bool {
+	c := text[i]
+	if isDigit(c) {
+		return true
+	}
+	rest := text[i+1:]
+	switch c {
+	case '+', '-':
+		return strings.HasPrefix(rest, "Infinity") || strings.HasPrefix(rest, "NaN") ||
+			(len(rest) > 0 && (isDigit(rest[0]) || rest[0] == '.'))
+	case '.':
+		return len(rest) > 0 && isDigit(rest[0])
+	}
+	return false
+}
+
+// copyNumber writes the number starting at the given index of text to b in
+// the standard form and returns the index of its last byte.
+func copyNumber(b *strings.Builder, text string, start int) int {
+	i := start
+	sign := ""
+	switch text[i] {
+	case '-':
+		sign = "-"
+		i++
+	case '+':
+		i++
+	}
+	switch {
+	case strings.HasPrefix(text[i:], "Infinity"):
+		b.WriteString(sign + relaxedInfinity)
+		return i + len("Infinity") - 1
+	case strings.HasPrefix(text[i:], "NaN"):
+		b.WriteString("null")
+		return i + len("NaN") - 1
+	case strings.HasPrefix(text[i:], "0x") || strings.HasPrefix(text[i:], "0X"):
+		end := i + 2
+		for end < len(text) && isHexDigit(text[end]) {
+			end++
+		}
+		if n, err := strconv.ParseUint(text[i+2:end], 16, 64); err == nil {
+			b.WriteString(sign + strconv.FormatUint(n, 10))
+		} else {
+			b.WriteString(text[start:end])
+		}
+		return end - 1
+	}
+	end := i
+	for end < len(text) && isDigit(text[end]) {
+		end++
+	}
+	integer := text[i:end]
+	if integer == "" {
+		integer = "0"
+	}
+	b.WriteString(sign + integer)
+	if end < len(text) && text[end] == '.' {
+		fractionStart := end + 1
+		end = fractionStart
+		for end < len(text) && isDigit(text[end]) {
+			end++
+		}
+		if end > fractionStart {
+			b.WriteString(text[fractionStart-1 : end])
+		}
+	}
+	if end < len(text) && (text[end] == 'e' || text[end] == 'E') {
+		exponentStart := end
+		end++
+		if end < len(text) && (text[end] == '+' || text[end] == '-') {
+			end++
+		}
+		for end < len(text) && isDigit(text[end]) {
+			end++
+		}
+		b.WriteString(text[exponentStart:end])
+	}
+	return end - 1
+}
+
+// removeTrailingCommas removes commas that are followed only by whitespace
+// before a closing '}' or ']'. Commas within strings are preserved.
+func removeTrailingCommas(text string) string {
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			if c == '\\' && i+1 < len(text) {
+				b.WriteByte(c)
+				i++
+				c = text[i]
+			} else if c == '"' {
+				inString = false
+			}
+			b.WriteByte(c)
+			continue
+		}
+		if c == '"' {
+			inString = true
+		}
+		if c == ',' {
+			next := strings.TrimLeft(text[i+1:], " \t\r\n")
+			if strings.HasPrefix(next, "}") || strings.HasPrefix(next, "]") {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// isIdentifierStart returns true if the given byte can start an unquoted key.
+func isIdentifierStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isIdentifierPart returns true if the given byte can continue an unquoted key.
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || isDigit(c)
+}
+
+// isDigit returns true if the given byte is a decimal digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isHexDigit returns true if the given byte is a hexadecimal digit.
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
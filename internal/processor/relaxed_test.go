@@ -0,0 +1,66 @@
+package processor
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNormalizeRelaxedJSON(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`{"a":1,"b":[true,null]}`, `{"a":1,"b":[true,null]}`},
+		{`{"a":1} // trailing comment`, `{"a":1} `},
+		{`{"a":/* inline */1}`, `{"a":1}`},
+		{"{\"a\":1, /* one\n two */ \"b\":2}", "{\"a\":1,  \"b\":2}"},
+		{"{\n  a: 1, // first\n  b: 2,\n}", "{\n  \"a\": 1, \n  \"b\": 2\n}"},
+		{`{"a":[1,2,],}`, `{"a":[1,2]}`},
+		{`{a:1, $b_2 : 2}`, `{"a":1, "$b_2" : 2}`},
+		{`{'a':'it\'s "x"'}`, `{"a":"it's \"x\""}`},
+		{`{"url":"http://x//y","c":"/* no */"}`, `{"url":"http://x//y","c":"/* no */"}`},
+		{`{"s":"a,]"}`, `{"s":"a,]"}`},
+		{`['\x41\v\0é\n']`, `["\u0041\u000b\u0000é\n"]`},
+		{"['one \\\ntwo']", `["one two"]`},
+		{`[0x1F, -0XfF, +1, .5, 5., +.5e3, 1e-3, -2]`, `[31, -255, 1, 0.5, 5, 0.5e3, 1e-3, -2]`},
+		{`[Infinity, -Infinity, +Infinity, NaN, -NaN]`, `[1.7976931348623157e+308, -1.7976931348623157e+308, 1.7976931348623157e+308, null, null]`},
+		{`{Infinity: 1, NaN: 2}`, `{"Infinity": 1, "NaN": 2}`},
+	}
+	for _, test := range tests {
+		got := NormalizeRelaxedJSON(test.text)
+		if got != test.want {
+			t.Errorf("NormalizeRelaxedJSON(%q) = %q, want %q", test.text, got, test.want)
+		}
+		if !json.Valid([]byte(got)) {
+			t.Errorf("NormalizeRelaxedJSON(%q) = %q is not valid JSON", test.text, got)
+		}
+	}
+}
+
+func TestSplitRelaxedValue(t *testing.T) {
+	text := "// header {\n/* a } */ {a: '}', b: \"]\", // }\n c: [1]}\n{d: 2}\n{e: 'open"
+	var records []string
+	for {
+		record, rest, found := SplitRelaxedValue([]byte(text))
+		if !found {
+			break
+		}
+		records = append(records, NormalizeRelaxedJSON(string(record)))
+		text = string(rest)
+	}
+	want := []string{
+		"\n {\"a\": \"}\", \"b\": \"]\", \n \"c\": [1]}",
+		"\n{\"d\": 2}",
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got records %q, want %q", records, want)
+	}
+	for i := range want {
+		if records[i] != want[i] {
+			t.Errorf("record %d = %q, want %q", i, records[i], want[i])
+		}
+	}
+	if text != "\n{e: 'open" {
+		t.Errorf("got rest %q, want the incomplete record", text)
+	}
+}
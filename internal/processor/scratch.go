@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// ScratchResult is a tea.Msg that conveys the output of a scratch program run
+// by the processor. If the program failed then Err is set and Message holds
+// the stderr of jq.
+type ScratchResult struct {
+	Lines   []string
+	Message string
+	Err     error
+	Jq      string
+}
+
+// scratchFlags returns the flags of the jq invocation that runs the scratch
+// program of the given Command. Unlike the content and groups queries, the
+// program is given the records of the file as parsed by jq rather than raw
+// lines.
+func scratchFlags(cmd Command) []string {
+	flags := []string{"-r"}
+	if cmd.SortKeys {
+		flags = append(flags, "-S")
+	}
+	return append(flags, indentFlags(cmd)...)
+}
+
+// runScratch runs the scratch program of the given Command over the current
+// contents of the file and sends the result to the program as a ScratchResult
+// message. Nothing is sent if the run is canceled.
+func runScratch(args streamArgs) {
+	flags := scratchFlags(args.cmd)
+	jqCmdString := jqProgram(args.cmd) + " " + strings.Join(flags, " ") + " " + shellQuote(args.cmd.Program) + " " + shellQuote(args.cmd.Path)
+	jqCmd := jqCommand(args.ctx, args.cmd, append(flags, args.cmd.Program, args.cmd.Path)...)
+	output, err := jqCmd.Output()
+	if args.ctx.Err() != nil {
+		return
+	}
+	result := ScratchResult{Jq: jqCmdString}
+	if err != nil {
+		result.Err = err
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			result.Message = strings.TrimSpace(string(exitErr.Stderr))
+		}
+	}
+	if len(output) > 0 {
+		result.Lines = splitLines(output)
+		if truncateLines(result.Lines, args.cmd.MaxLineBytes) {
+			args.reportTruncated()
+		}
+	}
+	args.program.Send(result)
+}
+
+// startOnce cancels the given run, if any, and calls run with the given
+// streamArgs in the background. It returns the cancel function of the new run.
+func startOnce(cancel func(), run func(streamArgs), args streamArgs) func() {
+	if cancel != nil {
+		cancel()
+	}
+	args.ctx, args.cancel = context.WithCancel(context.Background())
+	go runRecovered(run, args, contentPanic)
+	return args.cancel
+}
@@ -0,0 +1,52 @@
+package processor
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sender sends messages to the program. It is implemented by *tea.Program.
+type sender interface {
+	Send(msg tea.Msg)
+}
+
+// TailStart is a tea.Msg that indicates the processor is (re)starting a read
+// for the raw tail pane.
+type TailStart struct {
+	InitialContent []string
+}
+
+// TailLine is a tea.Msg that conveys a line of content for the raw tail pane.
+type TailLine struct {
+	Line string
+}
+
+// TailError is a tea.Msg that conveys an error that occurred when reading
+// content for the raw tail pane.
+type TailError struct {
+	Message string
+	Err     error
+	Jq      string
+}
+
+// tailSender is a sender that converts the messages of a content stream into
+// the messages of the raw tail pane so that the tail can be streamed like the
+// content. The jq command of the tail, and waiting for the file, which the
+// content reports, are not reported.
+type tailSender struct {
+	program sender
+}
+
+// Send implements sender.
+func (t tailSender) Send(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case ContentStart:
+		t.program.Send(TailStart{InitialContent: msg.InitialContent})
+	case ContentLine:
+		t.program.Send(TailLine{Line: msg.Line})
+	case ContentError:
+		t.program.Send(TailError{Message: msg.Message, Err: msg.Err, Jq: msg.Jq})
+	case JQCommand, WaitingForFile:
+	default:
+		t.program.Send(msg)
+	}
+}
@@ -0,0 +1,43 @@
+package processor
+
+import (
+	"context"
+	"time"
+)
+
+// pacer spaces out the lines sent to the program so that no more than a given
+// number of lines are sent per second. A nil pacer does not wait.
+type pacer struct {
+	interval time.Duration
+	next     time.Time
+}
+
+// newPacer returns a pacer that allows the given number of lines per second,
+// or nil if linesPerSecond is less than 1.
+func newPacer(linesPerSecond int) *pacer {
+	if linesPerSecond < 1 {
+		return nil
+	}
+	return &pacer{interval: time.Second / time.Duration(linesPerSecond)}
+}
+
+// wait blocks until the next line may be sent. It returns false if the given
+// context is done first.
+func (p *pacer) wait(ctx context.Context) bool {
+	if p == nil {
+		return true
+	}
+	now := time.Now()
+	if p.next.After(now) {
+		timer := time.NewTimer(p.next.Sub(now))
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+		}
+		now = p.next
+	}
+	p.next = now.Add(p.interval)
+	return true
+}
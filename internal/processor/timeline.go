@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Timeline is a tea.Msg that conveys how many of the selected records fall in
+// each of the equal width time buckets between Start and End, according to the
+// time field of the records. Records whose time field is missing or cannot be
+// parsed are not counted. If jq failed then Err is set and Message holds the
+// stderr of jq.
+type Timeline struct {
+	Start   time.Time
+	End     time.Time
+	Counts  []int
+	Message string
+	Err     error
+	Jq      string
+}
+
+// createJQTimelineQuery returns a jq query string that outputs the time field
+// of each record selected by the given Command as compact JSON, one per line.
+// Records that cause errors are skipped.
+func createJQTimelineQuery(cmd Command) string {
+	return fmt.Sprintf("inputs|(%s|%s|select(. != null))?", createJQFilter(cmd), cmd.TimeField)
+}
+
+// bucketTimes returns the earliest and latest of the given times and the
+// number of times in each of the given number of equal width buckets between
+// them. If every time is the same then there is a single bucket.
+func bucketTimes(times []time.Time, buckets int) (time.Time, time.Time, []int) {
+	if len(times) == 0 {
+		return time.Time{}, time.Time{}, nil
+	}
+	start, end := times[0], times[0]
+	for _, t := range times {
+		if t.Before(start) {
+			start = t
+		}
+		if t.After(end) {
+			end = t
+		}
+	}
+	if start.Equal(end) {
+		return start, end, []int{len(times)}
+	}
+	buckets = max(buckets, 1)
+	span := end.Sub(start)
+	counts := make([]int, buckets)
+	for _, t := range times {
+		i := int(float64(t.Sub(start)) / float64(span) * float64(buckets))
+		counts[min(i, buckets-1)]++
+	}
+	return start, end, counts
+}
+
+// runTimeline buckets the times of the records of the given Command over the
+// current contents of the file and sends the result to the program as a
+// Timeline message. Nothing is sent if the run is canceled.
+func runTimeline(args streamArgs) {
+	query := createJQTimelineQuery(args.cmd)
+	flags := []string{"-nRc"}
+	jqCmdString := jqProgram(args.cmd) + " " + strings.Join(flags, " ") + " " + shellQuote(query) + " " + shellQuote(args.cmd.Path)
+	jqCmd := jqCommand(args.ctx, args.cmd, append(flags, query, args.cmd.Path)...)
+	output, err := jqCmd.Output()
+	if args.ctx.Err() != nil {
+		return
+	}
+	timeline := Timeline{Jq: jqCmdString}
+	if err != nil {
+		timeline.Err = err
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			timeline.Message = strings.TrimSpace(string(exitErr.Stderr))
+		}
+		args.program.Send(timeline)
+		return
+	}
+	var times []time.Time
+	for _, line := range bytes.Split(output, []byte("\n")) {
+		var value any
+		if json.Unmarshal(line, &value) != nil {
+			continue
+		}
+		if t, ok := ParseTimestamp(value); ok {
+			times = append(times, t)
+		}
+	}
+	timeline.Start, timeline.End, timeline.Counts = bucketTimes(times, args.cmd.Buckets)
+	args.program.Send(timeline)
+}
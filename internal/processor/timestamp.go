@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// timestampLayouts are the layouts tried, in order, when parsing a timestamp
+// string.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.UnixDate,
+	time.ANSIC,
+	// JavaScript Date.toString() with the zone name removed.
+	"Mon Jan 02 2006 15:04:05 GMT-0700",
+}
+
+// zoneNameSuffix matches the parenthesized zone name that JavaScript appends to
+// Date.toString(), like " (Eastern Daylight Time)".
+var zoneNameSuffix = regexp.MustCompile(`\s*\([^)]*\)$`)
+
+// ParseTimestamp returns the time represented by the given value from a parsed
+// record. Strings are parsed with the timestampLayouts and numbers are treated
+// as seconds, or milliseconds if too large to be seconds, since the Unix epoch.
+// It returns false if the value cannot be interpreted as a time.
+func ParseTimestamp(value any) (time.Time, bool) {
+	switch value := value.(type) {
+	case float64:
+		return epochTime(value), true
+	case string:
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return epochTime(number), true
+		}
+		value = zoneNameSuffix.ReplaceAllString(value, "")
+		for _, layout := range timestampLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// epochTime returns the time for the given number of seconds since the Unix
+// epoch. Numbers too large to be seconds are treated as milliseconds.
+func epochTime(number float64) time.Time {
+	if number > 1e11 {
+		return time.UnixMilli(int64(number))
+	}
+	return time.Unix(0, int64(number*float64(time.Second)))
+}
@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampPatterns are the jq regular expressions of the timestamp strings
+// that epochQuery reads, the timestampLayouts of ParseTimestamp. Each captures
+// the year y, the month as a number m or a name b, the day d, the time t, and
+// the fraction of a second f and the zone z, which may be left out. A zone name
+// is read as UTC, as time.Parse does for names it does not know.
+var timestampPatterns = []string{
+	// RFC 3339, which may use a space rather than a "T".
+	`^(?<y>[0-9]{4})-(?<m>[0-9]{2})-(?<d>[0-9]{2})[T ](?<t>[0-9]{2}:[0-9]{2}:[0-9]{2})(?<f>[.][0-9]+)?(?<z>Z|[+-][0-9]{2}:?[0-9]{2})?$`,
+	// RFC 1123, with a zone name or offset.
+	`^(?:Mon|Tue|Wed|Thu|Fri|Sat|Sun), (?<d>[0-9]{2}) (?<b>[A-Z][a-z]{2}) (?<y>[0-9]{4}) (?<t>[0-9]{2}:[0-9]{2}:[0-9]{2})(?<f>[.][0-9]+)? (?<z>[+-][0-9]{4}|[A-Z]+)$`,
+	// Unix date, and ANSI C, which has no zone.
+	`^(?:Mon|Tue|Wed|Thu|Fri|Sat|Sun) (?<b>[A-Z][a-z]{2}) +(?<d>[0-9]{1,2}) (?<t>[0-9]{2}:[0-9]{2}:[0-9]{2})(?<f>[.][0-9]+)?(?: (?<z>[A-Z]+))? (?<y>[0-9]{4})$`,
+	// JavaScript Date.toString(), with or without the zone name.
+	`^(?:Mon|Tue|Wed|Thu|Fri|Sat|Sun) (?<b>[A-Z][a-z]{2}) (?<d>[0-9]{2}) (?<y>[0-9]{4}) (?<t>[0-9]{2}:[0-9]{2}:[0-9]{2})(?<f>[.][0-9]+)? GMT(?<z>[+-][0-9]{4})(?: [(][^)]*[)])?$`,
+}
+
+// capturedEpochQuery is a jq query that converts the captures of a
+// timestampPattern to seconds since the Unix epoch.
+const capturedEpochQuery = `. as $c|([($c.y|tonumber), ` +
+	`(if $c.m then ($c.m|tonumber)-1 else "JanFebMarAprMayJunJulAugSepOctNovDec"|index($c.b)/3 end), ` +
+	`($c.d|tonumber), ($c.t|split(":")[]|tonumber), 0, 0]|mktime)` +
+	`+("0"+($c.f//"")|tonumber)` +
+	`-($c.z//"Z"|if test("^[+-]") then gsub(":";"")|(if .[0:1]=="-" then -1 else 1 end)*((.[1:3]|tonumber)*3600+(.[3:5]|tonumber)*60) else 0 end)`
+
+// epochQuery is a jq query that converts a timestamp to seconds since the Unix
+// epoch, like ParseTimestamp. Numbers too large to be seconds are treated as
+// milliseconds, and strings must be numbers or match a timestampPattern.
+// Anything else produces no output.
+var epochQuery = `if type=="number" then (if . > 1e11 then ./1000 else . end) ` +
+	`elif type=="string" then (tonumber? // ((first(` + captureQueries(timestampPatterns) + `)|` + capturedEpochQuery + `)?)) ` +
+	`else empty end`
+
+// captureQueries returns the jq queries that capture each of the given
+// patterns, separated by commas.
+func captureQueries(patterns []string) string {
+	captures := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		captures[i] = "capture(" + jqString(pattern) + ")"
+	}
+	return strings.Join(captures, ", ")
+}
+
+// timeWindowQuery returns a jq query that selects the records whose time field
+// is within the Since and Until of the given Command, or "" if the Command has
+// neither. Records without a time that can be read are not selected.
+func timeWindowQuery(cmd Command) string {
+	var bounds []string
+	if !cmd.Since.IsZero() {
+		bounds = append(bounds, ". >= "+epochSeconds(cmd.Since))
+	}
+	if !cmd.Until.IsZero() {
+		bounds = append(bounds, ". <= "+epochSeconds(cmd.Until))
+	}
+	if len(bounds) == 0 || cmd.TimeField == "" {
+		return ""
+	}
+	return fmt.Sprintf("|select(%s|(%s)|%s)", cmd.TimeField, epochQuery, strings.Join(bounds, " and "))
+}
+
+// epochSeconds returns the given time as seconds since the Unix epoch.
+func epochSeconds(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/float64(time.Second), 'f', -1, 64)
+}
+
+// ParseTimeBound returns the time given by a bound of a time window, relative
+// to the given time now. The bound may be "now", a duration before now like
+// "now-1h" or just "1h", or a timestamp that ParseTimestamp understands.
+func ParseTimeBound(bound string, now time.Time) (time.Time, error) {
+	if bound == "now" {
+		return now, nil
+	}
+	if d, err := time.ParseDuration(strings.TrimPrefix(bound, "now-")); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, ok := ParseTimestamp(bound); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a duration, now-<duration>, or timestamp", bound)
+}
@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"encoding/json"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEpochQueryMatchesParseTimestamp(t *testing.T) {
+	if _, err := exec.LookPath("jq"); err != nil {
+		t.Skip("jq not found")
+	}
+	values := []any{
+		1530000721.0,
+		1530000721000.0,
+		"1530000721",
+		"2018-06-26T08:12:01Z",
+		"2018-06-26T08:12:01.25-04:00",
+		"2018-06-26 08:12:01",
+		"Tue, 26 Jun 2018 08:12:01 -0400",
+		"Tue, 26 Jun 2018 08:12:01 EDT",
+		"Tue Jun 26 08:12:01 EDT 2018",
+		"Tue Jun  6 08:12:01 2018",
+		"Tue Jun 26 2018 08:12:01 GMT-0400 (Eastern Daylight Time)",
+		"Tue Jun 26 2018 08:12:01 GMT+0530",
+	}
+	for _, value := range values {
+		want, ok := ParseTimestamp(value)
+		if !ok {
+			t.Fatalf("ParseTimestamp(%q) failed", value)
+		}
+		input, _ := json.Marshal(value)
+		output, err := exec.Command("jq", "-n", strconv.Quote(string(input))+"|fromjson|"+epochQuery).CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v: %v: %s", value, err, output)
+		}
+		got, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+		if err != nil {
+			t.Errorf("%v: got %q, want %d", value, output, want.Unix())
+			continue
+		}
+		if seconds := float64(want.UnixNano()) / float64(time.Second); math.Abs(got-seconds) > 1e-3 {
+			t.Errorf("%v: got %v, want %v", value, got, seconds)
+		}
+	}
+	for _, value := range []string{`"yesterday"`, `null`, `"Xyz Jun 26 2018 08:12:01 GMT-0400"`} {
+		output, err := exec.Command("jq", "-n", value+"|"+epochQuery).CombinedOutput()
+		if err != nil || len(output) != 0 {
+			t.Errorf("%s: got %q and %v, want no output", value, output, err)
+		}
+	}
+}
+
+func TestTimeWindowQuery(t *testing.T) {
+	records := []string{
+		`{"ts":"Tue Jun 26 2018 08:00:00 GMT-0400 (Eastern Daylight Time)","n":1}`,
+		`{"ts":"2018-06-26T13:00:00Z","n":2}`,
+		`{"ts":1530028800,"n":3}`,
+		`{"n":4}`,
+	}
+	at := func(s string) time.Time {
+		t, _ := time.Parse(time.RFC3339, s)
+		return t
+	}
+	tests := []struct {
+		name         string
+		since, until time.Time
+		want         string
+	}{
+		{"since", at("2018-06-26T12:30:00Z"), time.Time{}, "2,3"},
+		{"until", time.Time{}, at("2018-06-26T13:00:00Z"), "1,2"},
+		{"both", at("2018-06-26T12:30:00Z"), at("2018-06-26T13:30:00Z"), "2"},
+		{"neither", time.Time{}, time.Time{}, "1,2,3,4"},
+	}
+	for _, test := range tests {
+		cmd := Command{TimeField: ".ts", Since: test.since, Until: test.until, Format: ".n", Group: "*"}
+		got := runJQ(t, cmd, createJQContentQuery(cmd), records...)
+		if strings.Join(got, ",") != test.want {
+			t.Errorf("%s: got %q, want %s", test.name, got, test.want)
+		}
+	}
+	if query := timeWindowQuery(Command{Since: at("2018-06-26T12:30:00Z")}); query != "" {
+		t.Errorf("got %q without a time field, want no query", query)
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		bound string
+		want  time.Time
+	}{
+		{"now", now},
+		{"now-1h", now.Add(-time.Hour)},
+		{"1h", now.Add(-time.Hour)},
+		{"90m", now.Add(-90 * time.Minute)},
+		{"2024-04-30T08:00:00Z", time.Date(2024, 4, 30, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		got, err := ParseTimeBound(test.bound, now)
+		if err != nil || !got.Equal(test.want) {
+			t.Errorf("ParseTimeBound(%q) = %v, %v, want %v", test.bound, got, err, test.want)
+		}
+	}
+	for _, bound := range []string{"", "now-", "soon", "now+1h"} {
+		if _, err := ParseTimeBound(bound, now); err == nil {
+			t.Errorf("ParseTimeBound(%q) succeeded, want an error", bound)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// truncationPollInterval is how often the watched file is checked for having
+// been truncated.
+const truncationPollInterval = time.Second
+
+// truncationWatch cancels its context when the watched file shrinks, which
+// happens when a log is truncated rather than rotated. tail -f would carry on
+// from the start of the truncated file but the lines read before it would no
+// longer match the file, so the read must be restarted instead.
+type truncationWatch struct {
+	ctx       context.Context
+	cancel    func()
+	truncated atomic.Bool
+}
+
+// watchTruncation starts watching the file at the given path. The returned
+// watch's context is derived from the given context and is canceled when the
+// file shrinks. The watch must be stopped when it is no longer needed.
+func watchTruncation(ctx context.Context, path string) *truncationWatch {
+	watch := &truncationWatch{}
+	watch.ctx, watch.cancel = context.WithCancel(ctx)
+	go func() {
+		size := fileSize(path)
+		ticker := time.NewTicker(truncationPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-watch.ctx.Done():
+				return
+			case <-ticker.C:
+				newSize := fileSize(path)
+				if newSize < size {
+					watch.truncated.Store(true)
+					watch.cancel()
+					return
+				}
+				size = newSize
+			}
+		}
+	}()
+	return watch
+}
+
+// stop stops watching the file and returns whether the file was truncated
+// while it was watched.
+func (w *truncationWatch) stop() bool {
+	w.cancel()
+	return w.truncated.Load()
+}
+
+// fileSize returns the size of the file at the given path, or 0 if it cannot be
+// determined.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,39 @@
+package processor
+
+import (
+	"os"
+	"time"
+)
+
+// filePollInterval is how often a file that does not exist yet is checked for
+// having been created.
+const filePollInterval = 500 * time.Millisecond
+
+// WaitingForFile is a tea.Msg that indicates the file at Path does not exist
+// yet. Reading starts once it is created.
+type WaitingForFile struct {
+	Path string
+}
+
+// waitForFile returns true once the file of the given streamArgs exists. If it
+// does not exist yet then a WaitingForFile message is sent to the program and
+// the file is polled until it is created. It returns false if the context of
+// the streamArgs is done first.
+func waitForFile(args streamArgs) bool {
+	if _, err := os.Stat(args.cmd.Path); err == nil {
+		return true
+	}
+	args.program.Send(WaitingForFile{Path: args.cmd.Path})
+	ticker := time.NewTicker(filePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-args.ctx.Done():
+			return false
+		case <-ticker.C:
+			if _, err := os.Stat(args.cmd.Path); err == nil {
+				return true
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// logDirPattern matches the files of a log directory, including rotated and
+// compressed files like app.json.1 and app.json.2.gz.
+const logDirPattern = "*.json*"
+
+// logDirPollInterval is how often the active file of a log directory is checked
+// for new content and rotation.
+const logDirPollInterval = time.Second
+
+// logFile is a file of a log directory.
+type logFile struct {
+	path    string
+	modTime time.Time
+	suffix  int
+}
+
+// listLogFiles returns the files of the given directory that match
+// logDirPattern, oldest first. Files are ordered by modification time. Files
+// with the same modification time are ordered by their numeric suffix, a higher
+// suffix being older, so app.json.2 comes before app.json.1 and app.json.
+func listLogFiles(dir string) ([]logFile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, logDirPattern))
+	if err != nil {
+		return nil, err
+	}
+	var files []logFile
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, logFile{path: path, modTime: info.ModTime(), suffix: numericSuffix(path)})
+	}
+	slices.SortStableFunc(files, func(a, b logFile) int {
+		if c := a.modTime.Compare(b.modTime); c != 0 {
+			return c
+		}
+		return b.suffix - a.suffix
+	})
+	return files, nil
+}
+
+// numericSuffix returns the number in the last extension of the given path,
+// ignoring a .gz extension, or 0 if there is none. For example, 2 for
+// app.json.2.gz.
+func numericSuffix(path string) int {
+	ext := filepath.Ext(strings.TrimSuffix(path, ".gz"))
+	n, err := strconv.Atoi(strings.TrimPrefix(ext, "."))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// streamLogDirToTmpFile creates a temp file and copies the files of the given
+// log directory to it, oldest first, so that they can be read as a single
+// file. The newest file is then followed like tail -F: new content is copied as
+// it is written and, when the file is rotated, the copy continues with the file
+// that replaces it. It returns the path to the created temp file and a cleanup
+// function.
+func streamLogDirToTmpFile(dir string) (string, func(), error) {
+	files, err := listLogFiles(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(files) == 0 {
+		return "", nil, fmt.Errorf("no files matching %s in %s", logDirPattern, dir)
+	}
+	tmpFile, err := os.CreateTemp("", "jlv")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+	}
+	out := &lineWriter{w: tmpFile}
+	for _, file := range files[:len(files)-1] {
+		if err := copyLogFile(out, file.path); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+	}
+	active, err := os.Open(files[len(files)-1].path)
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	if _, err := io.Copy(out, active); err != nil {
+		active.Close()
+		cleanup()
+		return "", nil, err
+	}
+	go followLogDir(dir, active, out)
+	return tmpFile.Name(), cleanup, nil
+}
+
+// followLogDir copies new content of the given active file to the given
+// writer. If the active file is replaced, renamed, or truncated, or a newer
+// file appears in the directory, the rest of the active file is copied and the
+// copy continues from the start of the newest file.
+func followLogDir(dir string, active *os.File, out *lineWriter) {
+	for range time.Tick(logDirPollInterval) {
+		if _, err := io.Copy(out, active); err != nil {
+			continue
+		}
+		files, err := listLogFiles(dir)
+		if err != nil || len(files) == 0 {
+			continue
+		}
+		newest := files[len(files)-1].path
+		activeInfo, err := active.Stat()
+		if err != nil {
+			continue
+		}
+		newestInfo, err := os.Stat(newest)
+		if err != nil {
+			continue
+		}
+		if os.SameFile(activeInfo, newestInfo) {
+			// Copy truncated files again from the start.
+			if offset, err := active.Seek(0, io.SeekCurrent); err == nil && newestInfo.Size() < offset {
+				active.Seek(0, io.SeekStart)
+			}
+			continue
+		}
+		next, err := os.Open(newest)
+		if err != nil {
+			continue
+		}
+		active.Close()
+		active = next
+		out.endLine()
+	}
+}
+
+// copyLogFile copies the file at the given path to the given writer,
+// decompressing it if it ends in .gz.
+func copyLogFile(out *lineWriter, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		return err
+	}
+	out.endLine()
+	return nil
+}
+
+// lineWriter is an io.Writer that remembers whether the last byte written was a
+// newline so that the content of consecutive files is not joined on one line.
+type lineWriter struct {
+	w       io.Writer
+	partial bool
+}
+
+// Write implements io.Writer.
+func (l *lineWriter) Write(p []byte) (int, error) {
+	n, err := l.w.Write(p)
+	if n > 0 {
+		l.partial = p[n-1] != '\n'
+	}
+	return n, err
+}
+
+// endLine writes a newline if the content written so far does not end with
+// one.
+func (l *lineWriter) endLine() {
+	if l.partial {
+		l.Write([]byte{'\n'})
+	}
+}
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/docopt/docopt-go"
@@ -11,45 +18,345 @@ import (
 	"github.com/mrxk/jlv/internal/processor"
 )
 
+// Exit codes for the errors that scripts may want to tell apart. Other errors
+// exit with 1.
+const (
+	exitFileNotFound = 2
+	exitJqMissing    = 3
+	exitQueryError   = 4
+)
+
 const (
 	jsonlogUsage = `
 JSON log viewer: jlv
 
 Usage:
-	jlv [options] <path>
+	jlv [options] [--redact=<paths>]... <path>
 
 Options:
 	<path>                               The path of the JSON file to watch.
-	                                     "-" for stdin.
+	                                     "-" for stdin. A directory for the
+	                                     *.json* files in it, oldest first.
+	                                     An http:// or https:// URL to stream.
 	-s <selector>, --selector=<selector> JSON path to grouping field.
+	--group=<group>                      Group to select once the groups are read.
 	-o <format>, --output=<format>       Format of output.
+	--format-file=<path>                 Read the format of output from a jq file.
 	-l, --linenumbers                    Show line numbers.
 	-w, --wrap                           Wrap output.
+	-m <n>, --max-groups=<n>             Maximum number of groups to list.
+	--pin-groups=<groups>                Comma separated groups to list first, like error,fatal.
+	-r, --raw-selector                   Use the selector as the full jq filter.
+	-t <path>, --time-field=<path>       JSON path to a timestamp field.
+	--since=<time>                       Skip records before a time, like 1h or now-1h.
+	--until=<time>                       Skip records after a time, like 2024-05-01T12:00:00Z.
+	-d, --dedup                          Collapse repeated consecutive lines.
+	-p <command>, --pager=<command>      Command to page output.
+	--tabwidth=<n>                       Width of tab stops [default: 8].
+	--relaxed                            Accept relaxed JSON (JSON5) input. Infinity
+	                                     is read as the largest double and NaN as null.
+	-S, --sort-keys                      Sort the keys of output objects.
+	--indent=<n>                         Indent output objects with n spaces (1-7).
+	--tab                                Indent output objects with tabs.
+	-c, --changes                        Show only fields changed between records.
+	--lenient                            Skip records that cause jq errors.
+	--from-line=<n>                      Skip lines before line n of the file.
+	--last-lines=<n>                     Read only the last n lines at first and
+	                                     earlier lines on reaching the top.
+	--groups-layout=<layout>             Layout of groups: list or bar [default: list].
+	--follow-group=<policy>              Select new groups as they appear: newest or busiest.
+	--sanitize                           Escape control characters in the output.
+	--severity-field=<path>              JSON path to a severity field to count.
+	--severity-levels=<levels>           Severity levels to count [default: error,warn,info].
+	--redact=<paths>                     Replace the values at JSON paths with "***".
+	--alert=<predicate>                  Ring the bell for new records matching a jq predicate.
+	--mark=<predicate>                   Mark records matching a jq predicate to jump between.
+	--threshold-field=<path>             JSON path to a numeric field to filter with a slider.
+	-x, --exists                         Group by whether the selector path exists.
+	--max-line-bytes=<n>                 Truncate longer output lines [default: 1048576].
+	--wrap-indent=<marker>               Prefix wrapped continuation rows, like "↳ ".
+	--live-groups                        Select groups by filtering loaded records.
+	--idle-timeout=<duration>            Exit when no new content arrives for a duration.
+	--encoding=<name>                    Encoding of the file, like latin1 [default: utf-8].
+	-0, --nul-delimited                  Keep newlines inside formatted records.
+	-1, --one-line                       Show each record or formatted value on one line.
+	--hide-nulls                         Leave out null values of the output format.
+	--raw-fallback                       Show the JSON of records the output format does not fit.
+	--color-json                         Color keys, strings, numbers, and literals of records.
+	--buckets=<n>                        Number of buckets of the histogram [default: 10].
+	--throttle=<lines-per-second>        Limit the rate at which new lines are shown.
+	--pointer                            Write the selector as a JSON pointer, like /a/b.
+	--raw-tail                           Show the raw tail of the file above the output.
+	--no-env                             Hide environment variables from jq (env, $ENV).
+	--group-colors                       Color output lines by group when showing all groups.
+	--minimal                            Show only the output window and footer.
+	--time-deltas                        Show the time since the previous record in the gutter.
+	--source-lines                       Show the line number of each record in the file in the gutter.
+	--record-ids                         Show a short hash of each record in the gutter.
+	--theme-from-terminal                Pick colors that suit the background color of the terminal.
+	--reload-debounce=<ms>               Wait for typing to pause this long before reloading [default: 200].
+	--stdin-max-bytes=<n>                Keep only the newest lines of stdin or a URL past n bytes.
+	--record-delimiter=<delim>           Split the input into records on delim, like \x00.
 	`
 )
 
 // parseArgs takes a usage sting and returns a populated model.ModelOpts from
-// the current os.Args.
-func parseArgs(usage string) (model.ModelOpts, error) {
+// the given arguments.
+func parseArgs(usage string, args []string) (model.ModelOpts, error) {
 	opts := model.ModelOpts{}
-	docOpts, err := docopt.ParseDoc(usage)
+	docOpts, err := docopt.ParseArgs(usage, args, "")
 	if err != nil {
 		return opts, err
 	}
 	opts.Selector, _ = docOpts.String("--selector")
 	opts.Output, _ = docOpts.String("--output")
+	if formatFile, _ := docOpts.String("--format-file"); formatFile != "" {
+		if opts.Output != "" {
+			return opts, fmt.Errorf("--output and --format-file cannot be used together")
+		}
+		opts.Output, err = processor.ReadFormatFile(formatFile)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --format-file: %w", err)
+		}
+		opts.FormatFile = formatFile
+	}
 	opts.Path, _ = docOpts.String("<path>")
+	opts.Path = expandPath(opts.Path)
+	opts.Source = opts.Path
+	opts.Group, _ = docOpts.String("--group")
 	opts.LineNumbers, _ = docOpts.Bool("--linenumbers")
+	opts.TimeDeltas, _ = docOpts.Bool("--time-deltas")
+	opts.SourceLines, _ = docOpts.Bool("--source-lines")
+	opts.RecordIDs, _ = docOpts.Bool("--record-ids")
+	opts.ThemeFromTerminal, _ = docOpts.Bool("--theme-from-terminal")
 	opts.Wrap, _ = docOpts.Bool("--wrap")
+	opts.WrapIndent, _ = docOpts.String("--wrap-indent")
+	opts.RawSelector, _ = docOpts.Bool("--raw-selector")
+	opts.Exists, _ = docOpts.Bool("--exists")
+	opts.LiveGroups, _ = docOpts.Bool("--live-groups")
+	opts.TimeField, _ = docOpts.String("--time-field")
+	now := time.Now()
+	if opts.Since, err = timeBoundOption(docOpts, "--since", opts.TimeField, now); err != nil {
+		return opts, err
+	}
+	if opts.Until, err = timeBoundOption(docOpts, "--until", opts.TimeField, now); err != nil {
+		return opts, err
+	}
+	if !opts.Since.IsZero() && !opts.Until.IsZero() && opts.Until.Before(opts.Since) {
+		return opts, fmt.Errorf("--until is before --since")
+	}
+	opts.Dedup, _ = docOpts.Bool("--dedup")
+	opts.Pager, _ = docOpts.String("--pager")
+	opts.Relaxed, _ = docOpts.Bool("--relaxed")
+	opts.SortKeys, _ = docOpts.Bool("--sort-keys")
+	opts.Tab, _ = docOpts.Bool("--tab")
+	if indent, _ := docOpts.String("--indent"); indent != "" {
+		opts.Indent, err = strconv.Atoi(indent)
+		if err != nil || opts.Indent < 1 || opts.Indent > 7 {
+			return opts, fmt.Errorf("invalid --indent: %q", indent)
+		}
+	}
+	opts.DiffView, _ = docOpts.Bool("--changes")
+	opts.Lenient, _ = docOpts.Bool("--lenient")
+	if fromLine, _ := docOpts.String("--from-line"); fromLine != "" {
+		opts.FromLine, err = strconv.Atoi(fromLine)
+		if err != nil || opts.FromLine < 1 {
+			return opts, fmt.Errorf("invalid --from-line: %q", fromLine)
+		}
+	}
+	if lastLines, _ := docOpts.String("--last-lines"); lastLines != "" {
+		opts.LastLines, err = strconv.Atoi(lastLines)
+		if err != nil || opts.LastLines < 1 {
+			return opts, fmt.Errorf("invalid --last-lines: %q", lastLines)
+		}
+	}
+	opts.SeverityField, _ = docOpts.String("--severity-field")
+	if severityLevels, _ := docOpts.String("--severity-levels"); severityLevels != "" {
+		opts.SeverityLevels = strings.Split(severityLevels, ",")
+	}
+	if redact, ok := docOpts["--redact"].([]string); ok {
+		for _, paths := range redact {
+			opts.Redact = append(opts.Redact, strings.Split(paths, ",")...)
+		}
+	}
+	if pinGroups, _ := docOpts.String("--pin-groups"); pinGroups != "" {
+		opts.PinGroups = strings.Split(pinGroups, ",")
+	}
+	opts.Encoding, _ = docOpts.String("--encoding")
+	if _, err := processor.LookupEncoding(opts.Encoding); err != nil {
+		return opts, fmt.Errorf("invalid --encoding: %w", err)
+	}
+	opts.NulDelimited, _ = docOpts.Bool("--nul-delimited")
+	opts.OneLine, _ = docOpts.Bool("--one-line")
+	opts.HideNulls, _ = docOpts.Bool("--hide-nulls")
+	opts.RawFallback, _ = docOpts.Bool("--raw-fallback")
+	opts.ColorJSON, _ = docOpts.Bool("--color-json")
+	opts.Sanitize, _ = docOpts.Bool("--sanitize")
+	opts.Alert, _ = docOpts.String("--alert")
+	opts.Mark, _ = docOpts.String("--mark")
+	opts.ThresholdField, _ = docOpts.String("--threshold-field")
+	opts.GroupsLayout, _ = docOpts.String("--groups-layout")
+	if opts.GroupsLayout != "list" && opts.GroupsLayout != "bar" {
+		return opts, fmt.Errorf("invalid --groups-layout: %q", opts.GroupsLayout)
+	}
+	opts.FollowGroup, _ = docOpts.String("--follow-group")
+	if opts.FollowGroup != "" && opts.FollowGroup != "newest" && opts.FollowGroup != "busiest" {
+		return opts, fmt.Errorf("invalid --follow-group: %q", opts.FollowGroup)
+	}
+	opts.Buckets, err = docOpts.Int("--buckets")
+	if err != nil || opts.Buckets < 1 {
+		return opts, fmt.Errorf("invalid --buckets: %q", docOpts["--buckets"])
+	}
+	if throttle, _ := docOpts.String("--throttle"); throttle != "" {
+		opts.Throttle, err = strconv.Atoi(throttle)
+		if err != nil || opts.Throttle < 1 {
+			return opts, fmt.Errorf("invalid --throttle: %q", throttle)
+		}
+	}
+	opts.Pointer, _ = docOpts.Bool("--pointer")
+	opts.RawTail, _ = docOpts.Bool("--raw-tail")
+	opts.NoEnv, _ = docOpts.Bool("--no-env")
+	opts.GroupColors, _ = docOpts.Bool("--group-colors")
+	opts.Minimal, _ = docOpts.Bool("--minimal")
+	if stdinMaxBytes, _ := docOpts.String("--stdin-max-bytes"); stdinMaxBytes != "" {
+		opts.StdinMaxBytes, err = strconv.ParseInt(stdinMaxBytes, 10, 64)
+		if err != nil || opts.StdinMaxBytes < minStdinMaxBytes {
+			return opts, fmt.Errorf("invalid --stdin-max-bytes: %q", stdinMaxBytes)
+		}
+	}
+	if recordDelimiter, _ := docOpts.String("--record-delimiter"); recordDelimiter != "" {
+		opts.RecordDelimiter, err = parseRecordDelimiter(recordDelimiter)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --record-delimiter: %w", err)
+		}
+	}
+	if maxGroups, _ := docOpts.String("--max-groups"); maxGroups != "" {
+		opts.MaxGroups, err = strconv.Atoi(maxGroups)
+		if err != nil || opts.MaxGroups < 1 {
+			return opts, fmt.Errorf("invalid --max-groups: %q", maxGroups)
+		}
+	}
+	opts.MaxLineBytes, err = docOpts.Int("--max-line-bytes")
+	if err != nil {
+		return opts, fmt.Errorf("invalid --max-line-bytes: %w", err)
+	}
+	if idleTimeout, _ := docOpts.String("--idle-timeout"); idleTimeout != "" {
+		opts.IdleTimeout, err = time.ParseDuration(idleTimeout)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --idle-timeout: %w", err)
+		}
+	}
+	reloadDebounce, err := docOpts.Int("--reload-debounce")
+	if err != nil || reloadDebounce < 0 {
+		return opts, fmt.Errorf("invalid --reload-debounce: %q", docOpts["--reload-debounce"])
+	}
+	opts.ReloadDebounce = time.Duration(reloadDebounce) * time.Millisecond
+	opts.TabWidth, err = docOpts.Int("--tabwidth")
+	if err != nil {
+		return opts, fmt.Errorf("invalid --tabwidth: %w", err)
+	}
 	return opts, nil
 }
 
-// streamStdinToTmpFile creates a temp file and copies stdin to that file.  It
-// returns the path to the created temp file, a cleanup function, and a channel
-// that will be written to when all data has been read from stdin.  If streaming
-// from a process that does not stop, like `tail -f`, the channel will never be
-// written to and never closed.
-func streamStdinToTmpFile() (string, func(), <-chan struct{}) {
+// expandPath expands environment variables and a leading ~ in the given path.
+// The "-" path for stdin, URLs and paths that exist as given are returned
+// unchanged, so that file names containing $ or ~ still open.
+func expandPath(path string) string {
+	if path == "-" || isURL(path) {
+		return path
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// exitCode returns the exit code for the given error.
+func exitCode(err error) int {
+	var queryErr processor.QueryError
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return exitFileNotFound
+	case errors.Is(err, exec.ErrNotFound):
+		return exitJqMissing
+	case errors.As(err, &queryErr):
+		return exitQueryError
+	}
+	return 1
+}
+
+// exit prints the given error to stderr and exits with its exit code.
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, "jlv: "+err.Error())
+	os.Exit(exitCode(err))
+}
+
+// checkStart returns an error if jlv cannot start with the given options:
+// neither the file nor its directory exists, jq is not installed, or jq rejects
+// the initial query. A file that does not exist yet in an existing directory is
+// waited for.
+func checkStart(opts model.ModelOpts) error {
+	if opts.Path != "-" && !isURL(opts.Path) {
+		if _, err := os.Stat(opts.Path); errors.Is(err, fs.ErrNotExist) {
+			if _, err := os.Stat(filepath.Dir(opts.Path)); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+	if _, err := exec.LookPath("jq"); err != nil {
+		return err
+	}
+	if opts.Pointer && !opts.RawSelector {
+		if _, err := processor.PointerTokens(opts.Selector); err != nil {
+			return processor.QueryError{Message: err.Error(), Err: err, Jq: opts.Selector}
+		}
+	}
+	return processor.CheckQueries(processor.Command{
+		Selector:    opts.Selector,
+		Format:      opts.Output,
+		RawSelector: opts.RawSelector,
+		Exists:      opts.Exists,
+		Pointer:     opts.Pointer,
+		Redact:      opts.Redact,
+		Lenient:     opts.Lenient,
+	})
+}
+
+// timeBoundOption returns the time given by the named bound of the time window,
+// relative to the given time now, or the zero time if the option is not set.
+// The bound requires a time field.
+func timeBoundOption(docOpts docopt.Opts, name, timeField string, now time.Time) (time.Time, error) {
+	value, _ := docOpts.String(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if timeField == "" {
+		return time.Time{}, fmt.Errorf("%s requires --time-field", name)
+	}
+	t, err := processor.ParseTimeBound(value, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return t, nil
+}
+
+// streamToTmpFile creates a temp file and copies the reader returned by open to
+// that file.  It returns the path to the created temp file, a cleanup function,
+// and a channel that will be sent the error, if any, when all data has been
+// read.  If streaming from a process that does not stop, like `tail -f`, the
+// channel will never be sent to and never closed. If maxBytes is positive then
+// the temp file is trimmed to its newest lines whenever it grows beyond
+// maxBytes. open is called from a go routine, as opening a named pipe blocks
+// until something opens it for writing.
+func streamToTmpFile(open func() (io.ReadCloser, error), maxBytes int64) (string, func(), <-chan error) {
 	tmpFile, err := os.CreateTemp("", "jlv")
 	if err != nil {
 		panic(err)
@@ -59,43 +366,119 @@ func streamStdinToTmpFile() (string, func(), <-chan struct{}) {
 		tmpFile.Close()
 		os.Remove(tmpFile.Name())
 	}
-	// Spawn a go routine to continually copy data from stdin to the tmp file.
-	// Signal done if/when the read is complete.
-	done := make(chan struct{})
+	// Spawn a go routine to continually copy data from the reader to the tmp
+	// file. Signal done if/when the read is complete.
+	done := make(chan error, 1)
 	go func() {
-		io.Copy(tmpFile, os.Stdin)
-		done <- struct{}{}
+		r, err := open()
+		if err == nil {
+			_, err = io.Copy(&cappedFile{file: tmpFile, maxBytes: maxBytes}, r)
+			r.Close()
+		}
+		done <- err
 		close(done)
 	}()
 	return path, cleanup, done
 }
 
 func main() {
-	opts, err := parseArgs(jsonlogUsage)
+	profilePath, args := takeProfileFlag(os.Args[1:])
+	opts, err := parseArgs(jsonlogUsage, args)
 	if err != nil {
 		panic(err)
 	}
+	if err := checkStart(opts); err != nil {
+		exit(err)
+	}
+	stopProfile := func() {}
+	if profilePath != "" {
+		stopProfile, err = startProfile(profilePath)
+		if err != nil {
+			exit(err)
+		}
+	}
 	// If reading from stdin, cache data in a temp file so that changing
 	// selector and output format can be applied to content displayed in the
 	// output window and not just content that arrives on stdin after the change
 	// has been made.
-	var stdInDone <-chan struct{}
+	var streamDone <-chan error
+	streamName := "Stdin"
+	cleanup := func() {}
 	if opts.Path == "-" {
-		var cleanup func()
-		opts.Path, cleanup, stdInDone = streamStdinToTmpFile()
+		opts.Path, cleanup, streamDone = streamToTmpFile(openStdin, opts.StdinMaxBytes)
+		defer cleanup()
+	} else if isNamedPipe(opts.Path) {
+		// A named pipe can be read only once, and not from the end like a
+		// file, so it is cached like stdin.
+		streamName = opts.Path
+		opts.Path, cleanup, streamDone = streamToTmpFile(openFile(opts.Path), opts.StdinMaxBytes)
+		defer cleanup()
+	} else if isURL(opts.Path) {
+		// Cache the response like stdin, reconnecting when the connection
+		// is lost.
+		opts.Path, cleanup, err = streamURLToTmpFile(opts.Path, opts.StdinMaxBytes)
+		if err != nil {
+			exit(err)
+		}
+		defer cleanup()
+	} else if info, err := os.Stat(opts.Path); err == nil && info.IsDir() {
+		// Read the files of a directory of rotated logs as one file.
+		opts.Path, cleanup, err = streamLogDirToTmpFile(opts.Path)
+		if err != nil {
+			exit(err)
+		}
 		defer cleanup()
 	}
+	var split recordSplit
+	if opts.RecordDelimiter != "" {
+		split = delimiterSplit(opts.RecordDelimiter)
+	} else if opts.Relaxed && relaxedPrettyPrinted(opts.Path) {
+		split = processor.SplitRelaxedValue
+	} else if !opts.Relaxed && prettyPrinted(opts.Path) {
+		split = jsonValueSplit
+	}
+	if split != nil && opts.Relaxed {
+		// Records are joined onto one line, which would run a line
+		// comment into the rest of the record, so they are normalized
+		// first.
+		split = normalizedSplit(split)
+	}
+	if split != nil {
+		// Give jq the records one per line.
+		inputCleanup := cleanup
+		var recordsCleanup func()
+		opts.Path, recordsCleanup, err = streamRecordsToTmpFile(opts.Path, split)
+		if err != nil {
+			cleanup()
+			exit(err)
+		}
+		defer recordsCleanup()
+		cleanup = func() {
+			recordsCleanup()
+			inputCleanup()
+		}
+	}
 	p := tea.NewProgram(model.NewModel(opts), tea.WithAltScreen(), tea.WithInputTTY())
 	go processor.Run(p)
-	if _, err := p.Run(); err != nil {
+	finalModel, err := p.Run()
+	stopProfile()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
-	if stdInDone != nil {
+	if streamDone != nil {
 		select {
-		case <-stdInDone:
+		case err := <-streamDone:
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "jlv: "+err.Error())
+			}
 		default:
-			fmt.Println("Stdin may not be closed. Ctrl-C to exit.")
+			fmt.Println(streamName + " may not be closed. Ctrl-C to exit.")
 		}
 	}
+	if err := finalModel.(*model.Model).Err(); err != nil {
+		// Deferred calls do not run on exit.
+		cleanup()
+		exit(err)
+	}
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := t.TempDir()
+	literal := filepath.Join(dir, "$HOME.json")
+	if err := os.WriteFile(literal, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"~", home},
+		{"~/x", filepath.Join(home, "x")},
+		{"$HOME/x", filepath.Join(home, "x")},
+		{"${HOME}/x", filepath.Join(home, "x")},
+		{"-", "-"},
+		{"https://example.com/$HOME/~", "https://example.com/$HOME/~"},
+		{"~user/x", "~user/x"},
+		{literal, literal},
+	}
+	for _, test := range tests {
+		if got := expandPath(test.path); got != test.want {
+			t.Errorf("expandPath(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestParseArgsMaxGroups(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+		err   bool
+	}{
+		{"1", 1, false},
+		{"25", 25, false},
+		{"0", 0, true},
+		{"-3", 0, true},
+		{"many", 0, true},
+	}
+	for _, test := range tests {
+		opts, err := parseArgs(jsonlogUsage, []string{"--max-groups=" + test.value, "log.json"})
+		switch {
+		case test.err && (err == nil || !strings.Contains(err.Error(), "invalid --max-groups")):
+			t.Errorf("--max-groups=%s: err = %v, want an invalid --max-groups error", test.value, err)
+		case !test.err && (err != nil || opts.MaxGroups != test.want):
+			t.Errorf("--max-groups=%s: MaxGroups = %d, %v, want %d", test.value, opts.MaxGroups, err, test.want)
+		}
+	}
+}
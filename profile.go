@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+)
+
+// profileFlag is the hidden option that writes a CPU profile of jlv to the
+// given path, and a heap profile to the same path with a ".heap" suffix. It is
+// not in the usage, so it is taken out of the arguments before they are
+// parsed.
+const profileFlag = "--profile"
+
+// takeProfileFlag returns the path given to the profile flag, or "" if there
+// is none, and the given arguments without the flag. Both "--profile=<path>"
+// and "--profile <path>" are accepted. Arguments after "--" are left alone.
+func takeProfileFlag(args []string) (string, []string) {
+	path := ""
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--":
+			return path, append(rest, args[i:]...)
+		case strings.HasPrefix(arg, profileFlag+"="):
+			path = strings.TrimPrefix(arg, profileFlag+"=")
+		case arg == profileFlag && i+1 < len(args):
+			i++
+			path = args[i]
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return path, rest
+}
+
+// startProfile starts the CPU profile written to the given path. It returns
+// the function that stops it and writes the heap profile.
+func startProfile(path string) (func(), error) {
+	cpuFile, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(cpuFile); err != nil {
+		cpuFile.Close()
+		return nil, fmt.Errorf("profile: %w", err)
+	}
+	return func() {
+		pprof.StopCPUProfile()
+		cpuFile.Close()
+		heapFile, err := os.Create(path + ".heap")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "jlv: profile: "+err.Error())
+			return
+		}
+		defer heapFile.Close()
+		// Collect garbage first so that the profile shows live memory.
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(heapFile); err != nil {
+			fmt.Fprintln(os.Stderr, "jlv: profile: "+err.Error())
+		}
+	}, nil
+}
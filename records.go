@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+// parseRecordDelimiter returns the delimiter given to --record-delimiter with
+// Go escapes, like \x00, \t, and \n, replaced by the characters they stand for.
+func parseRecordDelimiter(s string) (string, error) {
+	delimiter, err := strconv.Unquote(`"` + s + `"`)
+	if err != nil {
+		return "", errors.New("invalid escape")
+	}
+	if delimiter == "" {
+		return "", errors.New("empty delimiter")
+	}
+	return delimiter, nil
+}
+
+// recordSplit returns the first complete record of the given text and the text
+// after it. It returns false if the text does not hold a complete record yet.
+type recordSplit func(text []byte) ([]byte, []byte, bool)
+
+// delimiterSplit returns a recordSplit that ends each record at the given
+// delimiter, which is dropped.
+func delimiterSplit(delimiter string) recordSplit {
+	return func(text []byte) ([]byte, []byte, bool) {
+		return bytes.Cut(text, []byte(delimiter))
+	}
+}
+
+// jsonValueSplit is a recordSplit that ends each record at the end of a top
+// level JSON object or array, for files of values that span several lines.
+// Brackets inside strings are ignored.
+func jsonValueSplit(text []byte) ([]byte, []byte, bool) {
+	depth := 0
+	inString, escaped := false, false
+	for i, c := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+			if depth <= 0 {
+				return text[:i+1], text[i+1:], true
+			}
+		}
+	}
+	return nil, text, false
+}
+
+// normalizedSplit returns a recordSplit that splits records like the given one
+// and converts each from relaxed JSON to standard JSON.
+func normalizedSplit(split recordSplit) recordSplit {
+	return func(text []byte) ([]byte, []byte, bool) {
+		record, rest, found := split(text)
+		if found {
+			record = []byte(processor.NormalizeRelaxedJSON(string(record)))
+		}
+		return record, rest, found
+	}
+}
+
+// recordWriter is an io.Writer that splits what is written to it into records
+// and writes each record to a file on one line, with its line breaks replaced
+// by spaces. JSON allows line breaks only between values, so this does not
+// change the records, and jq reads them one per line. Empty records are
+// dropped. The text after the last record is held until the record is
+// complete, unless it is already valid JSON, so that the last record of a file
+// is shown even without a delimiter after it.
+type recordWriter struct {
+	file    *os.File
+	split   recordSplit
+	pending []byte
+}
+
+// Write implements io.Writer.
+func (r *recordWriter) Write(p []byte) (int, error) {
+	r.pending = append(r.pending, p...)
+	for {
+		record, rest, found := r.split(r.pending)
+		if !found {
+			break
+		}
+		if err := r.writeRecord(record); err != nil {
+			return 0, err
+		}
+		r.pending = rest
+	}
+	if json.Valid(r.pending) {
+		if err := r.writeRecord(r.pending); err != nil {
+			return 0, err
+		}
+		r.pending = r.pending[:0]
+	}
+	return len(p), nil
+}
+
+// writeRecord writes the given record to the file on one line.
+func (r *recordWriter) writeRecord(record []byte) error {
+	record = bytes.TrimSpace(record)
+	if len(record) == 0 {
+		return nil
+	}
+	line := bytes.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, record)
+	_, err := r.file.Write(append(line, '\n'))
+	return err
+}
+
+// reset empties the file and drops any pending text, so that the processor
+// reads the records again from the start.
+func (r *recordWriter) reset() error {
+	r.pending = r.pending[:0]
+	if err := r.file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := r.file.Seek(0, io.SeekStart)
+	return err
+}
+
+// streamRecordsToTmpFile creates a temp file holding the records of the file
+// at the given path, split by the given recordSplit, one per line. The content
+// of the file is copied before it returns and the file is then followed: new
+// content is copied as it is written and, if the file is truncated, the copy
+// starts again from the beginning. A file that does not exist yet is copied
+// once it is created. It returns the path to the created temp file and a
+// cleanup function.
+func streamRecordsToTmpFile(path string, split recordSplit) (string, func(), error) {
+	tmpFile, err := os.CreateTemp("", "jlv")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+	}
+	out := &recordWriter{file: tmpFile, split: split}
+	file, err := os.Open(path)
+	if err == nil {
+		if _, err := io.Copy(out, file); err != nil {
+			file.Close()
+			cleanup()
+			return "", nil, err
+		}
+	}
+	go followRecords(path, file, out)
+	return tmpFile.Name(), cleanup, nil
+}
+
+// prettyPrinted returns true if the file at the given path starts with a JSON
+// object or array that does not end on its first line, as pretty printers
+// write them.
+func prettyPrinted(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			_, _, complete := jsonValueSplit(line)
+			return (line[0] == '{' || line[0] == '[') && !complete
+		}
+		if err != nil {
+			return false
+		}
+	}
+}
+
+// relaxedPrettyPrinted is prettyPrinted for relaxed JSON. The first value
+// counts as spanning several lines with any comments before it, so that a
+// header comment of several lines is read as part of the first record. Only
+// the start of the file is read.
+func relaxedPrettyPrinted(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	head := make([]byte, bufio.MaxScanTokenSize)
+	n, _ := io.ReadFull(file, head)
+	text := bytes.TrimSpace([]byte(processor.NormalizeRelaxedJSON(string(head[:n]))))
+	if len(text) == 0 || (text[0] != '{' && text[0] != '[') {
+		return false
+	}
+	record, _, complete := processor.SplitRelaxedValue(bytes.TrimSpace(head[:n]))
+	return !complete || bytes.ContainsRune(record, '\n')
+}
+
+// followRecords copies new content of the file at the given path, which is
+// open as the given file unless it is nil, to the given recordWriter. If the
+// file is truncated then the recordWriter is reset and the copy starts again
+// from the beginning.
+func followRecords(path string, file *os.File, out *recordWriter) {
+	for range time.Tick(logDirPollInterval) {
+		if file == nil {
+			var err error
+			if file, err = os.Open(path); err != nil {
+				file = nil
+				continue
+			}
+		}
+		if _, err := io.Copy(out, file); err != nil {
+			continue
+		}
+		info, err := file.Stat()
+		if err != nil {
+			continue
+		}
+		if offset, err := file.Seek(0, io.SeekCurrent); err == nil && info.Size() < offset {
+			if out.reset() == nil {
+				file.Seek(0, io.SeekStart)
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrxk/jlv/internal/processor"
+)
+
+func TestRelaxedPrettyPrinted(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"{a: 1,}\n{a: 2}\n", false},
+		{"// header\n{a: 1}\n{a: 2}\n", true},
+		{"/* one\n   two */ {a: 1}\n", true},
+		{"{\n  a: 1,\n}\n", true},
+		{"not json\n", false},
+		{"", false},
+	}
+	for i, test := range tests {
+		path := filepath.Join(dir, strings.Repeat("x", i+1))
+		if err := os.WriteFile(path, []byte(test.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if got := relaxedPrettyPrinted(path); got != test.want {
+			t.Errorf("relaxedPrettyPrinted(%q) = %v, want %v", test.content, got, test.want)
+		}
+	}
+	if !relaxedPrettyPrinted("testdata/pretty.json5") {
+		t.Error("testdata/pretty.json5 is not detected")
+	}
+}
+
+func TestRecordWriterNormalizesRelaxedRecords(t *testing.T) {
+	content, err := os.ReadFile("testdata/pretty.json5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.CreateTemp(t.TempDir(), "records")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	out := &recordWriter{file: file, split: normalizedSplit(processor.SplitRelaxedValue)}
+	if _, err := out.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	copied, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(copied), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d records, want 3: %q", len(lines), copied)
+	}
+	var last struct {
+		Message    string
+		Properties map[string]any
+	}
+	for _, line := range lines {
+		last.Properties = nil
+		if err := json.Unmarshal([]byte(line), &last); err != nil {
+			t.Errorf("record %q: %v", line, err)
+		}
+	}
+	if last.Message != `request failed: "timeout" ]}` || last.Properties["retries"] != 3.0 {
+		t.Errorf("got last record %+v", last)
+	}
+}
+
+func TestPrettyPrinted(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"{\"a\":1}\n{\"a\":2}\n", false},
+		{"\n\n{\n  \"a\": 1\n}\n", true},
+		{"[\n  1\n]\n", true},
+		{"plain text\n", false},
+		{"", false},
+	}
+	for i, test := range tests {
+		path := filepath.Join(dir, strings.Repeat("x", i+1))
+		if err := os.WriteFile(path, []byte(test.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if got := prettyPrinted(path); got != test.want {
+			t.Errorf("prettyPrinted(%q) = %v, want %v", test.content, got, test.want)
+		}
+	}
+	if !prettyPrinted("testdata/pretty.json") {
+		t.Error("testdata/pretty.json is not detected")
+	}
+	if prettyPrinted("testdata/crlf.json") {
+		t.Error("testdata/crlf.json, with one record per line, is detected")
+	}
+}
+
+func TestJSONValueSplit(t *testing.T) {
+	content, err := os.ReadFile("testdata/pretty.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := content
+	var records [][]byte
+	for {
+		record, rest, found := jsonValueSplit(text)
+		if !found {
+			break
+		}
+		records = append(records, record)
+		text = rest
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+	for _, record := range records {
+		if !json.Valid(record) {
+			t.Errorf("record %q is not valid JSON", record)
+		}
+	}
+	if len(bytes.TrimSpace(text)) != 0 {
+		t.Errorf("got rest %q, want only whitespace", text)
+	}
+	if _, _, found := jsonValueSplit(content[:len(content)/2]); !found {
+		t.Error("the first record is not split from half the file")
+	}
+	if _, _, found := jsonValueSplit([]byte("{\n  \"a\": \"}\",\n")); found {
+		t.Error("an incomplete record with a bracket in a string is split")
+	}
+}
+
+func TestStreamRecordsToTmpFileCountsRecords(t *testing.T) {
+	content, err := os.ReadFile("testdata/pretty.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "pretty.json")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	copyPath, cleanup, err := streamRecordsToTmpFile(path, jsonValueSplit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	lines := readLines(t, copyPath)
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines in the copy of %d lines, want 3", len(lines), bytes.Count(content, []byte("\n")))
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Errorf("line %q is not valid JSON", line)
+		}
+	}
+
+	// A record appended to the file is copied onto the next line.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("{\n  \"level\": \"info\"\n}\n")
+	file.Close()
+	deadline := time.Now().Add(5 * logDirPollInterval)
+	for len(lines) < 4 && time.Now().Before(deadline) {
+		time.Sleep(logDirPollInterval / 10)
+		lines = readLines(t, copyPath)
+	}
+	if len(lines) != 4 || lines[3] != `{   "level": "info" }` {
+		t.Errorf("got lines %q after appending a record", lines)
+	}
+}
+
+// readLines returns the lines of the file at the given path.
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// minStdinMaxBytes is the smallest cap accepted for the temp file that holds
+// stdin.
+const minStdinMaxBytes = 1024 * 1024
+
+// cappedFile is an io.Writer that appends to a file and, once the file grows
+// beyond maxBytes, trims it in place to its newest lines, about half of
+// maxBytes, so that an endless stream does not fill the disk. The file
+// shrinks when it is trimmed, which the processor treats like a truncated log
+// and reads again from the start. A maxBytes less than 1 never trims the file.
+type cappedFile struct {
+	file     *os.File
+	size     int64
+	maxBytes int64
+}
+
+// Write implements io.Writer.
+func (c *cappedFile) Write(p []byte) (int, error) {
+	n, err := c.file.Write(p)
+	c.size += int64(n)
+	if err != nil {
+		return n, err
+	}
+	if c.maxBytes > 0 && c.size > c.maxBytes {
+		if err := c.trim(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// trim keeps the newest half of maxBytes of the file, starting after the first
+// newline in it so that the file starts with a whole line.
+func (c *cappedFile) trim() error {
+	keep := make([]byte, c.maxBytes/2)
+	if _, err := c.file.ReadAt(keep, c.size-int64(len(keep))); err != nil && err != io.EOF {
+		return err
+	}
+	if i := bytes.IndexByte(keep, '\n'); i >= 0 {
+		keep = keep[i+1:]
+	}
+	if _, err := c.file.WriteAt(keep, 0); err != nil {
+		return err
+	}
+	if err := c.file.Truncate(int64(len(keep))); err != nil {
+		return err
+	}
+	c.size = int64(len(keep))
+	_, err := c.file.Seek(c.size, io.SeekStart)
+	return err
+}
+
+// openStdin returns stdin for streamToTmpFile. Closing it does nothing, as
+// stdin belongs to the process.
+func openStdin() (io.ReadCloser, error) {
+	return io.NopCloser(os.Stdin), nil
+}
+
+// openFile returns a function that opens the file at the given path for
+// streamToTmpFile.
+func openFile(path string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}
+}
+
+// isNamedPipe returns true if the given path is a named pipe (FIFO).
+func isNamedPipe(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&fs.ModeNamedPipe != 0
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// urlReconnectDelay is how long to wait before connecting to a URL again after
+// the connection is lost.
+const urlReconnectDelay = 2 * time.Second
+
+// isURL returns true if the given path is an HTTP or HTTPS URL.
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// urlStream copies the body of an HTTP response to a file. It counts the bytes
+// of the body received so far so that a lost connection can resume where it
+// stopped.
+type urlStream struct {
+	url      string
+	out      *lineWriter
+	received int64
+}
+
+// connect requests the URL, asking for the rest of the body if some of it was
+// already received. It returns the response and whether its body continues
+// what was received. A server that ignores the range starts over with a new
+// body, as log streams do.
+func (s *urlStream) connect(ctx context.Context) (*http.Response, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if s.received > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(s.received, 10)+"-")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && s.received > 0:
+		return resp, true, nil
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && s.received > 0:
+		// Nothing was added since the last connection.
+		resp.Body.Close()
+		return nil, false, io.EOF
+	case resp.StatusCode/100 != 2:
+		resp.Body.Close()
+		return nil, false, fmt.Errorf("%s: %s", s.url, resp.Status)
+	}
+	return resp, false, nil
+}
+
+// copy copies the body of the given response to the file. A body that does
+// not continue what was received starts on a new line.
+func (s *urlStream) copy(resp *http.Response, resumed bool) error {
+	defer resp.Body.Close()
+	if !resumed {
+		s.out.endLine()
+		s.received = 0
+	}
+	n, err := io.Copy(s.out, resp.Body)
+	s.received += n
+	return err
+}
+
+// follow copies the body of the given response to the file and then, until
+// the given context is canceled, connects to the URL again whenever the
+// connection is lost. A body that ends cleanly is only requested again from a
+// server that accepts ranges, as a server that cannot resume it would send
+// its records again.
+func (s *urlStream) follow(ctx context.Context, resp *http.Response, resumed bool) {
+	for {
+		err := s.copy(resp, resumed)
+		if err == nil && resp.Header.Get("Accept-Ranges") != "bytes" {
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(urlReconnectDelay):
+			}
+			if resp, resumed, err = s.connect(ctx); err == nil {
+				break
+			}
+		}
+	}
+}
+
+// streamURLToTmpFile creates a temp file and copies the body of the given
+// HTTP(S) URL to it, so that changing the selector and output format can be
+// applied to the content already received. The URL is connected to again when
+// the connection is lost. If maxBytes is positive then the temp file is trimmed
+// to its newest lines whenever it grows beyond maxBytes. It returns the path to
+// the created temp file and a cleanup function. An error is returned if the
+// first connection fails.
+func streamURLToTmpFile(url string, maxBytes int64) (string, func(), error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &urlStream{url: url}
+	resp, resumed, err := stream.connect(ctx)
+	if err != nil {
+		cancel()
+		return "", nil, err
+	}
+	tmpFile, err := os.CreateTemp("", "jlv")
+	if err != nil {
+		resp.Body.Close()
+		cancel()
+		return "", nil, err
+	}
+	cleanup := func() {
+		cancel()
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+	}
+	stream.out = &lineWriter{w: &cappedFile{file: tmpFile, maxBytes: maxBytes}}
+	go stream.follow(ctx, resp, resumed)
+	return tmpFile.Name(), cleanup, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFollowStopsAfterChunkedBodyWithoutRanges(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"a":1}` + "\n"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(`{"a":2}` + "\n"))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var out bytes.Buffer
+	stream := &urlStream{url: server.URL, out: &lineWriter{w: &out}}
+	resp, resumed, err := stream.connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ContentLength != -1 {
+		t.Fatalf("got content length %d, want a chunked response", resp.ContentLength)
+	}
+	done := make(chan struct{})
+	go func() {
+		stream.follow(ctx, resp, resumed)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(urlReconnectDelay / 2):
+		t.Fatal("follow did not stop after the body ended")
+	}
+	if want := "{\"a\":1}\n{\"a\":2}\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}